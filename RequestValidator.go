@@ -2,23 +2,134 @@ package RequestValidator
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"regexp"
-	"strings"
+	"reflect"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sanketj85/requestvalidator/core"
 	log "github.com/sirupsen/logrus"
 )
 
-type ResponseBody struct {
-	StatusCode int
-	Message    string
-	Body       struct{}
+// The validation rules live in the framework independent core package, which
+// does not import gin. They are re-exported here so gin users only need this
+// package; both names configure the same rules.
+type (
+	Logger           = core.Logger
+	ResponseBody     = core.ResponseBody
+	FieldError       = core.FieldError
+	Config           = core.Config
+	ConditionalRule  = core.ConditionalRule
+	MobileMode       = core.MobileMode
+	PasswordPolicy   = core.PasswordPolicy
+	FieldType        = core.FieldType
+	SchemaField      = core.SchemaField
+	Schema           = core.Schema
+	ValidationResult = core.ValidationResult
+	MultipartConfig  = core.MultipartConfig
+	SemVerRule       = core.SemVerRule
+)
+
+const (
+	DefaultMaxBodySize       = core.DefaultMaxBodySize
+	DefaultMaxStreamBodySize = core.DefaultMaxStreamBodySize
+	DefaultMaxDepth          = core.DefaultMaxDepth
+	MobileModeIndian         = core.MobileModeIndian
+	MobileModeE164           = core.MobileModeE164
+	TypeString               = core.TypeString
+	TypeNumber               = core.TypeNumber
+	TypeBool                 = core.TypeBool
+	TypeArray                = core.TypeArray
+	TypeObject               = core.TypeObject
+	SemVerOptional           = core.SemVerOptional
+	SemVerRequired           = core.SemVerRequired
+	SemVerForbidden          = core.SemVerForbidden
+)
+
+var (
+	ErrMalformedJSON        = core.ErrMalformedJSON
+	ErrMalformedForm        = core.ErrMalformedForm
+	ErrUnsupportedMediaType = core.ErrUnsupportedMediaType
+	ErrTooDeeplyNested      = core.ErrTooDeeplyNested
+	ErrDuplicateKey         = core.ErrDuplicateKey
+	ErrEmptyBody            = core.ErrEmptyBody
+	ErrReadBody             = core.ErrReadBody
+	ErrNotJSONObject        = core.ErrNotJSONObject
+	ErrMalformedBody        = core.ErrMalformedBody
+)
+
+var (
+	DefaultConfig              = core.DefaultConfig
+	ValidateJSON               = core.ValidateJSON
+	Validate                   = core.Validate
+	HTTPMiddleware             = core.HTTPMiddleware
+	HTTPMiddlewareWithConfig   = core.HTTPMiddlewareWithConfig
+	SetSkipPaths               = core.SetSkipPaths
+	SetMaxDepth                = core.SetMaxDepth
+	SetBlocklistPatterns       = core.SetBlocklistPatterns
+	SetAllowedFields           = core.SetAllowedFields
+	SetFieldNameRules          = core.SetFieldNameRules
+	RegisterRequiredFields     = core.RegisterRequiredFields
+	RegisterConditionalRule    = core.RegisterConditionalRule
+	RegisterExactlyOne         = core.RegisterExactlyOne
+	FieldEquals                = core.FieldEquals
+	SetGeneralFormatPattern    = core.SetGeneralFormatPattern
+	SetFreeTextFields          = core.SetFreeTextFields
+	SetNumericRange            = core.SetNumericRange
+	SetExpectedType            = core.SetExpectedType
+	RegisterValidator          = core.RegisterValidator
+	RegisterRegexValidator     = core.RegisterRegexValidator
+	RegisterPathValidator      = core.RegisterPathValidator
+	RegisterSanitizer          = core.RegisterSanitizer
+	DisableValidator           = core.DisableValidator
+	SetFieldLength             = core.SetFieldLength
+	SetMaxStringLength         = core.SetMaxStringLength
+	SetFieldAlias              = core.SetFieldAlias
+	SetAllowedValues           = core.SetAllowedValues
+	SetAllowedValuesIgnoreCase = core.SetAllowedValuesIgnoreCase
+	SetMobileMode              = core.SetMobileMode
+	SetIDKeyPattern            = core.SetIDKeyPattern
+	SetIDRules                 = core.SetIDRules
+	SetSemVerRules             = core.SetSemVerRules
+	RegisterContentTypeHandler = core.RegisterContentTypeHandler
+	RegisterSchemaType         = core.RegisterSchemaType
+	ValidateMultipartFiles     = core.ValidateMultipartFiles
+	SetOTPLength               = core.SetOTPLength
+	SetDateLayouts             = core.SetDateLayouts
+	SetEpochTimestamps         = core.SetEpochTimestamps
+	SetUUIDVersion             = core.SetUUIDVersion
+	SetPincodeKeys             = core.SetPincodeKeys
+	SetBase64Keys              = core.SetBase64Keys
+	SetHexKeys                 = core.SetHexKeys
+	SetBooleanKeys             = core.SetBooleanKeys
+	SetBooleanTokens           = core.SetBooleanTokens
+	SetRedactedFields          = core.SetRedactedFields
+	SetPasswordPolicy          = core.SetPasswordPolicy
+	InvalidResponseBody        = core.InvalidResponseBody
+	SetPassportPattern         = core.SetPassportPattern
+	SetAmountRules             = core.SetAmountRules
+	SetHSNLengths              = core.SetHSNLengths
+	SetPercentageAbove100      = core.SetPercentageAbove100
+	LoadRules                  = core.LoadRules
+	DefaultMessages            = core.DefaultMessages
+	RegisterMessages           = core.RegisterMessages
+)
+
+// The gin middlewares log through logrus, as they always have; core alone
+// defaults to log/slog so its other users need not depend on logrus
+func init() {
+	core.SetLogger(log.StandardLogger())
+}
+
+// SetLogger replaces the logger used by the package. Passing nil restores the
+// default logrus standard logger.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = log.StandardLogger()
+	}
+	core.SetLogger(l)
 }
 
 func BadRequest(c *gin.Context, Message string) {
@@ -29,10 +140,11 @@ func BadRequest(c *gin.Context, Message string) {
 	c.AbortWithStatusJSON(http.StatusBadRequest, response)
 }
 
-func UnprocessableEntity(c *gin.Context, Message string) {
+func UnprocessableEntity(c *gin.Context, Message string, Errors ...string) {
 	response := ResponseBody{
 		StatusCode: http.StatusUnprocessableEntity,
 		Message:    Message,
+		Errors:     Errors,
 	}
 	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, response)
 }
@@ -45,184 +157,334 @@ func SuccessResponse(c *gin.Context, Message string) {
 	c.JSON(http.StatusOK, response)
 }
 
-func requestBodyLogger(c *gin.Context) string {
-	requestBody, _ := io.ReadAll(c.Request.Body)
-	rdr1 := ioutil.NopCloser(bytes.NewBuffer(requestBody))
-	rdr2 := ioutil.NopCloser(bytes.NewBuffer(requestBody))
-	c.Request.Body = rdr2
-	return readBody(rdr1)
+func ValidateRequest() gin.HandlerFunc {
+	return ValidateRequestWithConfig(DefaultConfig())
 }
 
-func readBody(reader io.Reader) string {
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(reader)
-	s := buf.String()
-	return s
+// ValidateRequestWithConfig returns the validation middleware using cfg.
+func ValidateRequestWithConfig(cfg Config) gin.HandlerFunc {
+	return validateRequest(cfg.WithDefaults(), func(c *gin.Context, cfg Config, contentType string, body []byte) (interface{}, []FieldError, error) {
+		return core.ValidateBody(c.Request.Context(), cfg, contentType, body)
+	})
 }
 
-func ValidateRequest() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var jsonData map[string]interface{}
-		reqBody := requestBodyLogger(c)
-		json.NewDecoder(c.Request.Body).Decode(&jsonData)
-		//fmt.Println(reqBody)
-		//fmt.Println("jsonData: ", jsonData)
-		//Bind the incoming JSON to a map
-		// if err := c.ShouldBindJSON(&jsonData); err != nil {
-		// 	BadRequest(c, "Failed to bind JSON")
-		// 	return
-		// }
-		// fmt.Printf("jsonData: %#v\n", jsonData)
+// ValidateWith returns a middleware validating request bodies against schema
+// only, instead of the package-wide key-based rules, for endpoints needing
+// their own rules.
+func ValidateWith(schema Schema) gin.HandlerFunc {
+	return ValidateWithConfig(schema, DefaultConfig())
+}
+
+// ValidateWithConfig returns the schema validation middleware using cfg.
+func ValidateWithConfig(schema Schema, cfg Config) gin.HandlerFunc {
+	return validateRequest(cfg.WithDefaults(), func(c *gin.Context, cfg Config, contentType string, body []byte) (interface{}, []FieldError, error) {
+		return core.ValidateBodyWithSchema(c.Request.Context(), cfg, schema, contentType, body)
+	})
+}
+
+// ValidateInto returns a middleware validating request bodies with the rules
+// derived from the struct tags of prototype, a pointer to a struct, as
+// described for RegisterSchemaType, and decoding passing JSON bodies into a
+// new value of that type, read with GetValidatedStruct. Fields missing from
+// the struct get the package-wide key-based rules. It panics if prototype is
+// not a pointer to a struct or its tags are malformed.
+func ValidateInto(prototype interface{}) gin.HandlerFunc {
+	return ValidateIntoWithConfig(prototype, DefaultConfig())
+}
+
+// ValidateIntoWithConfig returns the struct tag validation middleware using cfg.
+func ValidateIntoWithConfig(prototype interface{}, cfg Config) gin.HandlerFunc {
+	t := reflect.TypeOf(prototype)
+	if t == nil || t.Kind() != reflect.Pointer {
+		panic(fmt.Sprintf("ValidateInto prototype must be a pointer to a struct, got %T", prototype))
+	}
+	if err := core.RegisterSchemaType(t); err != nil {
+		panic(err)
+	}
+	return validateRequest(cfg.WithDefaults(), func(c *gin.Context, cfg Config, contentType string, body []byte) (interface{}, []FieldError, error) {
+		target := reflect.New(t.Elem()).Interface()
+		jsonData, validationErrors, err := core.ValidateBodyInto(c.Request.Context(), cfg, target, contentType, body)
+		if err == nil && len(validationErrors) == 0 && jsonData != nil {
+			c.Set(validatedStructKey, target)
+		}
+		return jsonData, validationErrors, err
+	})
+}
 
-		var validationErrors []string
+// validateRequest returns the request body middleware checking bodies with
+// validate, which may store more results in the gin context
+func validateRequest(cfg Config, validate func(c *gin.Context, cfg Config, contentType string, body []byte) (interface{}, []FieldError, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) || core.IsTrustedRequest(cfg, c.GetHeader, c.Request.RemoteAddr) {
+			c.Next()
+			return
+		}
+		body, err := core.ReadBody(c.Writer, c.Request, cfg.MaxBodySize)
+		if err != nil {
+			core.NotifyValidationResult(cfg, routePath(c), nil, err)
+			BadRequest(c, core.ReadErrorMessage(err))
+			return
+		}
 
-		// Validate recursively
-		if err := validateNested(jsonData, &validationErrors); err != nil {
-			UnprocessableEntity(c, "Validation error")
+		if err := core.CheckEmptyBody(cfg, c.Request.Method, body); err != nil {
+			core.NotifyValidationResult(cfg, routePath(c), nil, err)
+			response := core.FailureResponse(cfg, err)
+			abortWithResponse(c, response)
+			return
+		}
+		jsonData, validationErrors, err := validate(c, cfg, c.GetHeader("Content-Type"), body)
+		if err != nil {
+			core.NotifyValidationResult(cfg, routePath(c), nil, err)
+			response := core.FailureResponse(cfg, err)
+			abortWithResponse(c, response)
 			return
 		}
 
 		// If there are validation errors, return them
-		if len(validationErrors) > 0 {
-			//c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": validationErrors})
-			log.Error("@Validation error:", validationErrors)
-			UnprocessableEntity(c, "invalid request")
+		if abortOnValidationErrors(c, cfg, validationErrors) {
 			return
 		}
 		// If validation succeeds, set the validated data in context
-		c.Set("reqBody", reqBody)
-		c.Set("jsonData", jsonData)
+		c.Set(reqBodyKey, core.RedactBody(body, jsonData))
+		c.Set(jsonDataKey, jsonData)
 		//SuccessResponse(c, "Validation successful")
 		c.Next()
 	}
 }
 
-func validateNested(input interface{}, validationErrors *[]string) error {
-	switch v := input.(type) {
-	case map[string]interface{}:
-		return validateNestedMap(v, validationErrors)
-	case []interface{}:
-		return validateNestedArray(v, validationErrors)
-	default:
-		if !isValidGeneralFormat(input) {
-			*validationErrors = append(*validationErrors, fmt.Sprintf("Invalid format for value '%v'", input))
-		}
-		return nil
-	}
+// ValidateStream returns a middleware for bulk endpoints receiving large
+// JSON arrays, validating the elements as they are read with
+// core.ValidateBodyStream instead of decoding the whole body at once. It
+// uses DefaultConfig with the DefaultMaxStreamBodySize limit.
+func ValidateStream() gin.HandlerFunc {
+	cfg := DefaultConfig()
+	cfg.MaxBodySize = DefaultMaxStreamBodySize
+	return ValidateStreamWithConfig(cfg)
 }
 
-func validateNestedMap(input map[string]interface{}, validationErrors *[]string) error {
-	for key, value := range input {
-		if value == nil {
-			continue // Skip validation for null values
+// ValidateStreamWithConfig returns the streaming middleware using cfg. The
+// body is read from the request as it is validated, capped by
+// cfg.MaxBodySize when positive. The handler reads an empty body unless
+// cfg.StreamKeepBody is set, and no data is stored under the context keys
+// read by GetValidatedData and GetRawBody.
+func ValidateStreamWithConfig(cfg Config) gin.HandlerFunc {
+	cfg = cfg.WithDefaults()
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) || core.IsTrustedRequest(cfg, c.GetHeader, c.Request.RemoteAddr) {
+			c.Next()
+			return
+		}
+		stream, err := core.StreamBody(c.Writer, c.Request, cfg.MaxBodySize)
+		if err != nil {
+			core.NotifyValidationResult(cfg, routePath(c), nil, err)
+			BadRequest(c, core.ReadErrorMessage(err))
+			return
+		}
+
+		var raw bytes.Buffer
+		if cfg.StreamKeepBody {
+			stream = io.TeeReader(stream, &raw)
 		}
-		if err := validateNested(value, validationErrors); err != nil {
-			return err
+		var validationErrors []FieldError
+		stream, err = core.CheckEmptyStream(cfg, c.Request.Method, stream)
+		if err == nil {
+			validationErrors, err = core.ValidateBodyStream(c.Request.Context(), cfg, c.GetHeader("Content-Type"), stream)
 		}
-		if err := validateField(key, getStringValue(value), validationErrors); err != nil {
-			return err
+		c.Request.Body = io.NopCloser(&raw)
+		if err != nil {
+			core.NotifyValidationResult(cfg, routePath(c), nil, err)
+			response := core.FailureResponse(cfg, err)
+			abortWithResponse(c, response)
+			return
+		}
+		if abortOnValidationErrors(c, cfg, validationErrors) {
+			return
 		}
+		c.Next()
 	}
-	return nil
 }
 
-func validateNestedArray(input []interface{}, validationErrors *[]string) error {
-	for _, item := range input {
-		if err := validateNested(item, validationErrors); err != nil {
-			return err
-		}
-	}
-	return nil
+// Context keys under which the body middlewares store the request
+const (
+	reqBodyKey         = "reqBody"
+	jsonDataKey        = "jsonData"
+	validatedStructKey = "validatedStruct"
+)
+
+// GetValidatedStruct returns the pointer to the struct decoded by the
+// ValidateInto middleware. It reports false when no JSON body was decoded.
+func GetValidatedStruct(c *gin.Context) (interface{}, bool) {
+	return c.Get(validatedStructKey)
 }
 
-func isValidGeneralFormat(value interface{}) bool {
-	switch v := value.(type) {
-	case string:
-		// Check if the string contains only alphanumeric, ., -, _
-		matched, _ := regexp.MatchString(`^[ @/=a-zA-Z0-9\.\-_]*$`, v)
-		return matched
-	case int, int32, int64, float32, float64:
-		// Numeric types, allow any numeric format
-		return true
-	default:
-		// For other types (arrays, etc.), currently assume valid
-		return true
+// GetValidatedData returns the JSON object decoded by the body middlewares.
+// It reports false when no body was validated or the body is not an object.
+func GetValidatedData(c *gin.Context) (map[string]interface{}, bool) {
+	data, ok := c.Get(jsonDataKey)
+	if !ok {
+		return nil, false
 	}
+	object, ok := data.(map[string]interface{})
+	return object, ok
 }
 
-// getStringValue attempts to convert the input value to string
-func getStringValue(value interface{}) string {
-	if str, ok := value.(string); ok {
-		return str
+// GetRawBody returns the validated request body, with redacted fields masked.
+// It reports false when no body middleware ran for the request.
+func GetRawBody(c *gin.Context) (string, bool) {
+	body, ok := c.Get(reqBodyKey)
+	if !ok {
+		return "", false
 	}
-	return fmt.Sprintf("%v", value) // Fallback to formatting as string
+	raw, ok := body.(string)
+	return raw, ok
 }
 
-// validateField validates a field and appends errors to the provided slice
-func validateField(key, value string, validationErrors *[]string) error {
-	switch key {
-	case "otp":
-		if err := validateOTP(value); err != nil {
-			*validationErrors = append(*validationErrors, err.Error())
+// ValidateQueryParams returns a middleware that validates the URL query
+// parameters with the same field rules applied to JSON bodies.
+func ValidateQueryParams() gin.HandlerFunc {
+	return ValidateQueryParamsWithConfig(DefaultConfig())
+}
+
+// ValidateQueryParamsWithConfig returns the query parameter middleware using cfg.
+func ValidateQueryParamsWithConfig(cfg Config) gin.HandlerFunc {
+	cfg = cfg.WithDefaults()
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) {
+			c.Next()
+			return
 		}
-	case "mobile", "contact", "phone":
-		if err := validateMobileFormat(value); err != nil {
-			*validationErrors = append(*validationErrors, err.Error())
+		validationErrors := core.ValidateValues(cfg, c.Request.URL.Query())
+		if abortOnValidationErrors(c, cfg, validationErrors) {
+			return
 		}
-	case "pan":
-		if err := validatePanFormat(value); err != nil {
-			*validationErrors = append(*validationErrors, err.Error())
+		c.Next()
+	}
+}
+
+// ValidatePathParams returns a middleware that validates URL path parameters,
+// e.g. the :id in /users/:id, with the same field rules applied to JSON bodies.
+func ValidatePathParams() gin.HandlerFunc {
+	return ValidatePathParamsWithConfig(DefaultConfig())
+}
+
+// ValidatePathParamsWithConfig returns the path parameter middleware using cfg.
+func ValidatePathParamsWithConfig(cfg Config) gin.HandlerFunc {
+	cfg = cfg.WithDefaults()
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) {
+			c.Next()
+			return
 		}
-	case "email":
-		if err := validateEmailFormat(value); err != nil {
-			*validationErrors = append(*validationErrors, err.Error())
+		params := make(map[string][]string, len(c.Params))
+		for _, param := range c.Params {
+			params[param.Key] = append(params[param.Key], param.Value)
 		}
-	default:
-		if strings.Contains(key, "id") {
-			if err := validateIDFormat(value); err != nil {
-				*validationErrors = append(*validationErrors, err.Error())
-			}
+		validationErrors := core.ValidateValues(cfg, params)
+		if abortOnValidationErrors(c, cfg, validationErrors) {
+			return
 		}
+		c.Next()
 	}
-	return nil
 }
 
-// validateMobileFormat validates mobile number format
-func validateMobileFormat(mobile string) error {
-	if matched, _ := regexp.MatchString(`^[0-9]{10}$`, mobile); !matched {
-		return errors.New("invalid mobile number format")
-	}
-	return nil
+// ValidateHeaders returns a middleware validating request headers with the
+// rules of field keys: headers maps header names to keys, e.g.
+// {"X-Request-Id": "uuid", "X-Client-Mobile": "mobile"}. Invalid headers are
+// rejected with 400.
+func ValidateHeaders(headers map[string]string) gin.HandlerFunc {
+	return ValidateHeadersWithConfig(headers, DefaultConfig())
 }
 
-// validatePanFormat validates PAN card number format
-func validatePanFormat(pan string) error {
-	if matched, _ := regexp.MatchString(`^[A-Z]{5}[0-9]{4}[A-Z]{1}$`, pan); !matched {
-		return errors.New("invalid PAN format")
+// ValidateHeadersWithConfig returns the header middleware using cfg. Failures
+// always use status 400, since bad headers are client errors.
+func ValidateHeadersWithConfig(headers map[string]string, cfg Config) gin.HandlerFunc {
+	cfg = cfg.WithDefaults()
+	cfg.ValidationStatus = http.StatusBadRequest
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) {
+			c.Next()
+			return
+		}
+		validationErrors := core.ValidateHeaderValues(cfg, headers, c.GetHeader)
+		if abortOnValidationErrors(c, cfg, validationErrors) {
+			return
+		}
+		c.Next()
 	}
-	return nil
 }
 
-// validateEmailFormat validates email format
-func validateEmailFormat(email string) error {
-	if matched, _ := regexp.MatchString(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`, email); !matched {
-		return errors.New("invalid email format")
+// ValidateMultipart returns a middleware checking the file parts of
+// multipart/form-data requests against mc, e.g. to reject ".exe" uploads.
+// Other requests pass through unchecked.
+func ValidateMultipart(mc MultipartConfig) gin.HandlerFunc {
+	return ValidateMultipartWithConfig(mc, DefaultConfig())
+}
+
+// ValidateMultipartWithConfig returns the multipart middleware using cfg. The
+// form is parsed with c.MultipartForm unless an earlier handler has parsed
+// it, keeping large files on disk, and the body is still capped at
+// cfg.MaxBodySize. File contents are never read. The temporary files of a
+// form parsed here are removed once the request is handled or rejected.
+func ValidateMultipartWithConfig(mc MultipartConfig, cfg Config) gin.HandlerFunc {
+	cfg = cfg.WithDefaults()
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) || c.ContentType() != "multipart/form-data" {
+			c.Next()
+			return
+		}
+		if c.Request.MultipartForm == nil {
+			if cfg.MaxBodySize > 0 {
+				c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxBodySize)
+			}
+			if _, err := c.MultipartForm(); err != nil {
+				core.NotifyValidationResult(cfg, routePath(c), nil, err)
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					BadRequest(c, core.ReadErrorMessage(err))
+					return
+				}
+				response := core.FailureResponse(cfg, ErrMalformedForm)
+				abortWithResponse(c, response)
+				return
+			}
+			defer c.Request.MultipartForm.RemoveAll()
+		}
+		validationErrors := core.ValidateMultipartFiles(mc, c.Request.MultipartForm.File)
+		if abortOnValidationErrors(c, cfg, validationErrors) {
+			return
+		}
+		c.Next()
 	}
-	return nil
 }
 
-// validateIDFormat validates ID format (alphanumeric)
-func validateIDFormat(value string) error {
-	if matched, _ := regexp.MatchString(`^[A-Za-z=0-9]*$`, value); !matched {
-		return errors.New("invalid ID format, should be alphanumeric")
+// routePath returns the matched route pattern, or the request path when no route matched
+func routePath(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
 	}
-	return nil
+	return c.Request.URL.Path
 }
 
-// validateOTP validates OTP format
-func validateOTP(otp string) error {
-	if matched, _ := regexp.MatchString(`^\d{6}$`, otp); !matched {
-		return errors.New("invalid OTP format")
+// abortOnValidationErrors aborts with the configured status if there are
+// validation errors, and reports whether it did. In ReportOnly mode the errors
+// are only reported and the request is never aborted. The outcome is passed to
+// cfg.OnValidationResult either way.
+func abortOnValidationErrors(c *gin.Context, cfg Config, validationErrors []FieldError) bool {
+	core.NotifyValidationResult(cfg, routePath(c), validationErrors, nil)
+	if len(validationErrors) == 0 {
+		return false
+	}
+	if cfg.ReportOnly {
+		core.ReportValidationErrors(cfg, validationErrors, c.Writer.Header().Set)
+		return false
 	}
-	return nil
+	response := core.ValidationErrorResponse(cfg, validationErrors, core.Locale(cfg, c.GetHeader("Accept-Language")))
+	abortWithResponse(c, response)
+	return true
+}
+
+// abortWithResponse aborts with response, using its status and content type
+func abortWithResponse(c *gin.Context, response ResponseBody) {
+	c.Header("Content-Type", response.ContentType()+"; charset=utf-8")
+	c.AbortWithStatusJSON(response.StatusCode, response)
 }
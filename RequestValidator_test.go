@@ -0,0 +1,465 @@
+package RequestValidator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serve sends a request with body through middleware to a handler echoing
+// the body it reads, and returns the recorded response
+func serve(middleware gin.HandlerFunc, method, contentType, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware)
+	router.Any("/*path", func(c *gin.Context) {
+		received, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(received))
+	})
+	req := httptest.NewRequest(method, "/users", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decodeResponse decodes a failure response body, failing the test unless it
+// has the ResponseBody shape
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) ResponseBody {
+	t.Helper()
+	var response ResponseBody
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response %s is not a ResponseBody: %v", w.Body, err)
+	}
+	if response.StatusCode != w.Code {
+		t.Errorf("StatusCode = %d, want the status %d", response.StatusCode, w.Code)
+	}
+	return response
+}
+
+func TestValidateRequestEmptyAndMalformedBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "empty body", cfg: DefaultConfig(), body: "", wantStatus: http.StatusOK},
+		{name: "empty body rejected", cfg: Config{RejectEmptyBody: true}, body: "", wantStatus: http.StatusBadRequest, wantMessage: "empty request body"},
+		{name: "blank body rejected", cfg: Config{RejectEmptyBody: true}, body: " \n", wantStatus: http.StatusBadRequest, wantMessage: "empty request body"},
+		{name: "valid body", cfg: DefaultConfig(), body: `{"mobile":"9876543210","user":{"email":"a@example.com"}}`, wantStatus: http.StatusOK},
+		{name: "malformed body", cfg: DefaultConfig(), body: `{"mobile" "9876543210"}`, wantStatus: http.StatusBadRequest, wantMessage: "malformed JSON body"},
+		{name: "custom decode status", cfg: Config{DecodeStatus: http.StatusUnprocessableEntity, DecodeMessage: "bad body"}, body: `{mobile}`, wantStatus: http.StatusUnprocessableEntity, wantMessage: "bad body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ValidateRequestWithConfig(tt.cfg), http.MethodPost, "application/json", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if w.Body.String() != tt.body {
+					t.Errorf("handler read %q, want %q", w.Body, tt.body)
+				}
+				return
+			}
+			if response := decodeResponse(t, w); !strings.HasPrefix(response.Message, tt.wantMessage) {
+				t.Errorf("Message = %q, want it to start with %q", response.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestValidateRequestRejectsMalformedJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"truncated object", "application/json", `{"mobile":"98765`, http.StatusBadRequest, "unexpected EOF"},
+		{"truncated array", "application/json", `[{"mobile":"9876543210"},`, http.StatusBadRequest, "unexpected EOF"},
+		{"trailing garbage", "application/json", `{"mobile":"9876543210"}garbage`, http.StatusBadRequest, "after top-level value"},
+		{"second document", "application/json", `{"mobile":"9876543210"} {}`, http.StatusBadRequest, "after top-level value"},
+		{"bare string", "application/json", `"hello"`, http.StatusOK, ""},
+		{"bare string with invalid characters", "application/json", `"<hello>"`, http.StatusUnprocessableEntity, "invalid request"},
+		{"unquoted string", "application/json", `hello`, http.StatusBadRequest, "invalid character 'h'"},
+		{"not JSON content type", "text/plain", `{"mobile":`, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ValidateRequest(), http.MethodPost, tt.contentType, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			if response := decodeResponse(t, w); !strings.Contains(response.Message, tt.wantMessage) {
+				t.Errorf("Message = %q, want it to contain %q", response.Message, tt.wantMessage)
+			}
+		})
+	}
+
+	w := serve(ValidateRequestWithConfig(Config{RequireJSONObject: true}), http.MethodPost, "application/json", `"hello"`)
+	if w.Code != http.StatusBadRequest || decodeResponse(t, w).Message != "expected JSON object" {
+		t.Errorf("bare string with RequireJSONObject: got %d %s, want 400 expected JSON object", w.Code, w.Body)
+	}
+}
+
+// failedFields returns the fields of the Details of a failure response
+func failedFields(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	var fields []string
+	for _, detail := range decodeResponse(t, w).Details {
+		fields = append(fields, detail.Field)
+	}
+	return fields
+}
+
+func TestValidateRequestTopLevelArray(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{"valid objects", `[{"mobile":"9876543210"},{"email":"a@example.com"}]`, http.StatusOK, nil},
+		{"invalid elements", `[{"mobile":"9876543210"},{"mobile":"12"},{"user":{"email":"bad"}}]`, http.StatusUnprocessableEntity, []string{"[1].mobile", "[2].user.email"}},
+		{"nested arrays", `[{"items":[{"pan":"ABCDE1234F"},{"pan":"bad"}]}]`, http.StatusUnprocessableEntity, []string{"[0].items[1].pan"}},
+		{"empty array", `[]`, http.StatusOK, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ValidateRequestWithConfig(Config{ReturnErrors: true}), http.MethodPost, "application/json", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			if got := failedFields(t, w); strings.Join(got, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("failed fields = %q, want %q", got, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateRequestMaxDepth(t *testing.T) {
+	objects := func(depth int) string {
+		return strings.Repeat(`{"a":`, depth) + `"x"` + strings.Repeat("}", depth)
+	}
+	arrays := func(depth int) string {
+		return strings.Repeat("[", depth) + `"x"` + strings.Repeat("]", depth)
+	}
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"objects at the limit", objects(DefaultMaxDepth), http.StatusOK},
+		{"objects past the limit", objects(DefaultMaxDepth + 1), http.StatusBadRequest},
+		{"arrays at the limit", arrays(DefaultMaxDepth), http.StatusOK},
+		{"arrays past the limit", arrays(DefaultMaxDepth + 1), http.StatusBadRequest},
+		{"far past the limit", objects(10000), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ValidateRequest(), http.MethodPost, "application/json", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK && decodeResponse(t, w).Message != ErrTooDeeplyNested.Error() {
+				t.Errorf("body = %s, want message %q", w.Body, ErrTooDeeplyNested)
+			}
+		})
+	}
+
+	SetMaxDepth(2)
+	defer SetMaxDepth(DefaultMaxDepth)
+	if w := serve(ValidateRequest(), http.MethodPost, "application/json", objects(3)); w.Code != http.StatusBadRequest {
+		t.Errorf("SetMaxDepth(2): depth 3 got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestValidateRequestErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"decode error", DefaultConfig(), `{"mobile":}`, http.StatusBadRequest, "malformed JSON body"},
+		{"validation error", DefaultConfig(), `{"mobile":"12"}`, http.StatusUnprocessableEntity, "invalid request"},
+		{"walk error", DefaultConfig(), strings.Repeat("[", 100) + strings.Repeat("]", 100), http.StatusBadRequest, "request too deeply nested"},
+		{"custom statuses", Config{DecodeStatus: http.StatusTeapot, ValidationStatus: http.StatusConflict}, `{"mobile":"12"}`, http.StatusConflict, "invalid request"},
+		{"custom decode status", Config{DecodeStatus: http.StatusTeapot, ValidationStatus: http.StatusConflict}, `{"mobile"`, http.StatusTeapot, "malformed JSON body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ValidateRequestWithConfig(tt.cfg), http.MethodPost, "application/json", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if response := decodeResponse(t, w); !strings.HasPrefix(response.Message, tt.wantMessage) {
+				t.Errorf("Message = %q, want it to start with %q", response.Message, tt.wantMessage)
+			}
+		})
+	}
+
+	t.Run("cancelled request", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(ValidateRequest())
+		router.POST("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"mobile":"9876543210"}`)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+		}
+	})
+}
+
+func TestValidateRequestScalarBodies(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"string", `"hello"`, http.StatusOK},
+		{"invalid string", `"<hello>"`, http.StatusUnprocessableEntity},
+		{"number", `42`, http.StatusOK},
+		{"negative float", `-1.5e3`, http.StatusOK},
+		{"true", `true`, http.StatusOK},
+		{"false", `false`, http.StatusOK},
+		{"null", `null`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ValidateRequest(), http.MethodPost, "application/json", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			w = serve(ValidateRequestWithConfig(Config{RequireJSONObject: true}), http.MethodPost, "application/json", tt.body)
+			if w.Code != http.StatusBadRequest || decodeResponse(t, w).Message != ErrNotJSONObject.Error() {
+				t.Errorf("RequireJSONObject: got %d %s, want 400 %q", w.Code, w.Body, ErrNotJSONObject)
+			}
+		})
+	}
+
+	w := serve(ValidateRequestWithConfig(Config{RequireJSONObject: true}), http.MethodPost, "application/json", `{"mobile":"9876543210"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("RequireJSONObject object body: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	// Well past DefaultMaxBodySize
+	large := "[" + strings.Repeat(`{"mobile":"9876543210"},`, 100000) + `{"mobile":"9876543210"}]`
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(large))
+	zw.Close()
+
+	tests := []struct {
+		name        string
+		cfg         *Config
+		body        string
+		gzip        bool
+		wantStatus  int
+		wantBody    string
+		wantHandler string
+	}{
+		{name: "large array", body: large, wantStatus: http.StatusOK},
+		{name: "large array kept", cfg: &Config{StreamKeepBody: true}, body: large, wantStatus: http.StatusOK, wantHandler: large},
+		{name: "invalid element", body: `[{"mobile":"9876543210"},{"mobile":"12"}]`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "truncated array", body: `[{"mobile":"9876543210"}`, wantStatus: http.StatusBadRequest},
+		{name: "trailing data", body: `[] {}`, wantStatus: http.StatusBadRequest},
+		{name: "object body", body: `{"mobile":"12"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "object body kept", cfg: &Config{StreamKeepBody: true}, body: `{"mobile":"9876543210"}`, wantStatus: http.StatusOK, wantHandler: `{"mobile":"9876543210"}`},
+		{name: "size limit", cfg: &Config{MaxBodySize: 1 << 10}, body: large, wantStatus: http.StatusBadRequest, wantBody: "request body too large"},
+		{name: "object size limit", cfg: &Config{MaxBodySize: 1 << 10}, body: `{"name":"` + strings.Repeat("a", 2<<10) + `"}`, wantStatus: http.StatusBadRequest, wantBody: "request body too large"},
+		{name: "decompressed size limit", cfg: &Config{MaxBodySize: 1 << 16}, body: compressed.String(), gzip: true, wantStatus: http.StatusBadRequest, wantBody: "request body too large"},
+		{name: "empty body", cfg: &Config{RejectEmptyBody: true}, body: " ", wantStatus: http.StatusBadRequest, wantBody: "empty request body"},
+		{name: "blank prefix kept", cfg: &Config{RejectEmptyBody: true, StreamKeepBody: true}, body: "  []", wantStatus: http.StatusOK, wantHandler: "  []"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := ValidateStream()
+			if tt.cfg != nil {
+				middleware = ValidateStreamWithConfig(*tt.cfg)
+			}
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware)
+			router.POST("/users", func(c *gin.Context) {
+				received, _ := io.ReadAll(c.Request.Body)
+				c.String(http.StatusOK, string(received))
+			})
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.gzip {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %.200s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code == http.StatusOK && w.Body.String() != tt.wantHandler {
+				t.Errorf("handler read %d bytes, want %d", w.Body.Len(), len(tt.wantHandler))
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestValidateInto(t *testing.T) {
+	type signup struct {
+		Mobile string `json:"mobile" validate:"mobile,required"`
+		Email  string `json:"email" validate:"email"`
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ValidateInto(&signup{}))
+	router.POST("/signup", func(c *gin.Context) {
+		target, ok := GetValidatedStruct(c)
+		if !ok {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.String(http.StatusOK, target.(*signup).Mobile)
+	})
+
+	tests := []struct {
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{`{"mobile":"9876543210","email":"a@example.com"}`, http.StatusOK, "9876543210"},
+		{`{"mobile":"9123456780"}`, http.StatusOK, "9123456780"},
+		{`{"email":"a@example.com"}`, http.StatusUnprocessableEntity, ""},
+		{`{"mobile":"12"}`, http.StatusUnprocessableEntity, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus || tt.wantBody != "" && w.Body.String() != tt.wantBody {
+			t.Errorf("%s: got %d %s, want %d %s", tt.body, w.Code, w.Body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestValidateIntoUnknownFormatPanics(t *testing.T) {
+	type signup struct {
+		Email string `json:"email" validate:"emial"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("ValidateInto did not panic for an unknown format")
+		}
+	}()
+	ValidateInto(&signup{})
+}
+
+func TestValidateMultipartRemovesTempFiles(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("document", "report.pdf")
+	part.Write(bytes.Repeat([]byte("x"), 4096))
+	form.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Spill every file part to disk
+	router.MaxMultipartMemory = 1
+	router.Use(ValidateMultipart(MultipartConfig{AllowedExtensions: []string{".pdf"}}))
+	var spilled int
+	router.POST("/upload", func(c *gin.Context) {
+		entries, _ := os.ReadDir(tmp)
+		spilled = len(entries)
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if spilled == 0 {
+		t.Fatal("the file part was not spilled to disk for the handler")
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) > 0 {
+		t.Errorf("%d temporary files left after the request", len(entries))
+	}
+}
+
+// recordingLogger keeps the messages logged through it
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Error(args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestValidateRequestReportOnly(t *testing.T) {
+	logs := &recordingLogger{}
+	SetLogger(logs)
+	defer SetLogger(nil)
+
+	var result ValidationResult
+	cfg := Config{
+		ReportOnly:         true,
+		WarningsHeader:     "X-Validation-Warnings",
+		OnValidationResult: func(r ValidationResult) { result = r },
+	}
+	w := serve(ValidateRequestWithConfig(cfg), http.MethodPost, "application/json", `{"mobile":"12","email":"bad"}`)
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"mobile":"12","email":"bad"}` {
+		t.Fatalf("got %d %s, want the handler to run with the body", w.Code, w.Body)
+	}
+	warnings := w.Header().Get("X-Validation-Warnings")
+	if !strings.Contains(warnings, "mobile") || !strings.Contains(warnings, "email") {
+		t.Errorf("X-Validation-Warnings = %q, want both fields", warnings)
+	}
+	if len(logs.messages) != 1 || !strings.HasPrefix(logs.messages[0], "@Validation warning:") {
+		t.Errorf("logged %q, want one @Validation warning", logs.messages)
+	}
+	if result.Passed || strings.Join(result.FailedFields, ",") != "email,mobile" {
+		t.Errorf("OnValidationResult got %+v, want a failed result for mobile and email", result)
+	}
+}
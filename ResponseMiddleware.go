@@ -0,0 +1,57 @@
+package RequestValidator
+
+import (
+	"bytes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanketj85/requestvalidator/core"
+)
+
+// responseRecorder buffers the response body written by the handlers so it can
+// be validated before it reaches the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// ValidateResponse validates JSON response bodies with the same rules as
+// requests, to catch handlers emitting non-conforming data. Violations are
+// logged; in gin.TestMode the response is replaced with a 500 listing them.
+// The response is buffered until the handlers return.
+func ValidateResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := c.Writer
+		recorder := &responseRecorder{ResponseWriter: writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = writer
+
+		body := recorder.body.Bytes()
+		contentType := writer.Header().Get("Content-Type")
+		if len(body) > 0 && core.IsJSONContentType(contentType) {
+			_, validationErrors, err := core.ValidateBody(c.Request.Context(), Config{}, contentType, body)
+			if err != nil {
+				validationErrors = []FieldError{{Code: "invalid_json", Message: err.Error()}}
+			}
+			if len(validationErrors) > 0 {
+				response := core.InvalidResponseBody(validationErrors)
+				if gin.Mode() == gin.TestMode {
+					writer.Header().Del("Content-Length")
+					c.JSON(response.StatusCode, response)
+					return
+				}
+			}
+		}
+		if len(body) > 0 {
+			writer.Write(body)
+		}
+	}
+}
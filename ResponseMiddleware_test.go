@@ -0,0 +1,32 @@
+package RequestValidator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateResponseLogLabel(t *testing.T) {
+	logs := &recordingLogger{}
+	SetLogger(logs)
+	defer SetLogger(nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ValidateResponse())
+	router.GET("/user", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"mobile": "12"})
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if len(logs.messages) != 1 || !strings.HasPrefix(logs.messages[0], "@Response validation error:") {
+		t.Errorf("logged %q, want one message labelled @Response validation error:", logs.messages)
+	}
+}
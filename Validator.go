@@ -0,0 +1,63 @@
+package RequestValidator
+
+import "github.com/gin-gonic/gin"
+
+// Validator builds a body validation middleware with rules of its own, for
+// route groups needing rules different from the package-wide ones set with
+// the Set and Register functions, which still apply unless overridden:
+//
+//	admin.Use(NewValidator().WithMobileMode(MobileModeE164).WithRequired("email").Handler())
+//
+// Create one with NewValidator. The With methods modify and return the same
+// Validator; middleware already built is not affected by later calls.
+type Validator struct {
+	cfg Config
+}
+
+// NewValidator returns a Validator using DefaultConfig.
+func NewValidator() *Validator {
+	return &Validator{cfg: DefaultConfig()}
+}
+
+// WithConfig replaces the options of v with cfg, including the rules set so
+// far, so it should be called before the other With methods.
+func (v *Validator) WithConfig(cfg Config) *Validator {
+	v.cfg = cfg
+	return v
+}
+
+// WithMobileMode validates mobile, contact and phone fields in mode.
+func (v *Validator) WithMobileMode(mode MobileMode) *Validator {
+	v.cfg.MobileMode = mode
+	return v
+}
+
+// WithRequired marks fields, given as dotted paths, as required. Calls are
+// cumulative.
+func (v *Validator) WithRequired(fields ...string) *Validator {
+	v.cfg.RequiredFields = append(append([]string(nil), v.cfg.RequiredFields...), fields...)
+	return v
+}
+
+// WithValidator validates fields named key with fn instead of the built-in
+// rules and any validator added with RegisterValidator.
+func (v *Validator) WithValidator(key string, fn func(value string) error) *Validator {
+	validators := make(map[string]func(value string) error, len(v.cfg.Validators)+1)
+	for k, f := range v.cfg.Validators {
+		validators[k] = f
+	}
+	validators[key] = fn
+	v.cfg.Validators = validators
+	return v
+}
+
+// Config returns the configuration built by v, e.g. for the fiber or echo
+// adapters.
+func (v *Validator) Config() Config {
+	return v.cfg
+}
+
+// Handler returns the gin body validation middleware using the rules of v.
+func (v *Validator) Handler() gin.HandlerFunc {
+	return ValidateRequestWithConfig(v.cfg)
+}
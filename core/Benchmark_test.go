@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// nestedPayload is a signup body mixing built-in fields, nested objects and
+// arrays, decoded as a request body would be
+var nestedPayload = []byte(`{
+	"name": "Asha Rao",
+	"mobile": "9876543210",
+	"email": "asha.rao@example.com",
+	"pan": "ABCDE1234F",
+	"address": {"line1": "12 MG Road", "pincode": "560001", "country": "IN"},
+	"nominees": [
+		{"name": "Ravi Rao", "mobile": "9123456780", "dob": "1990-04-12"},
+		{"name": "Meera Rao", "mobile": "9988776655", "dob": "1994-11-03"}
+	],
+	"preferences": {"notifications": {"sms": true, "push": false}, "currency": "INR"}
+}`)
+
+// BenchmarkValidateNested measures validating the nested payload 10,000
+// times per iteration, so regex compilation on a hot path would show
+func BenchmarkValidateNested(b *testing.B) {
+	jsonData, err := decodeJSON(nestedPayload)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10000; j++ {
+			if validationErrors, err := validateData(ctx, cfg, jsonData); err != nil || len(validationErrors) > 0 {
+				b.Fatalf("validateData() = %v, %v", validationErrors, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPMiddleware validates request bodies for net/http handlers using the
+// default configuration.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return HTTPMiddlewareWithConfig(DefaultConfig())(next)
+}
+
+// HTTPMiddlewareWithConfig returns a net/http middleware using cfg. It applies
+// the same rules and responses as the gin ValidateRequestWithConfig.
+func HTTPMiddlewareWithConfig(cfg Config) func(http.Handler) http.Handler {
+	cfg = cfg.WithDefaults()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsSkippedPath(r.URL.Path) || IsTrustedRequest(cfg, r.Header.Get, r.RemoteAddr) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := ReadBody(w, r, cfg.MaxBodySize)
+			if err != nil {
+				NotifyValidationResult(cfg, r.URL.Path, nil, err)
+				writeJSON(w, errorResponse(http.StatusBadRequest, ReadErrorMessage(err)))
+				return
+			}
+
+			if err := CheckEmptyBody(cfg, r.Method, body); err != nil {
+				NotifyValidationResult(cfg, r.URL.Path, nil, err)
+				writeJSON(w, FailureResponse(cfg, err))
+				return
+			}
+			_, validationErrors, err := ValidateBody(r.Context(), cfg, r.Header.Get("Content-Type"), body)
+			NotifyValidationResult(cfg, r.URL.Path, validationErrors, err)
+			if err != nil {
+				writeJSON(w, FailureResponse(cfg, err))
+				return
+			}
+
+			if len(validationErrors) > 0 {
+				if !cfg.ReportOnly {
+					writeJSON(w, ValidationErrorResponse(cfg, validationErrors, Locale(cfg, r.Header.Get("Accept-Language"))))
+					return
+				}
+				ReportValidationErrors(cfg, validationErrors, w.Header().Set)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeJSON writes response as JSON using its StatusCode as the HTTP status
+func writeJSON(w http.ResponseWriter, response ResponseBody) {
+	w.Header().Set("Content-Type", response.ContentType()+"; charset=utf-8")
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultMessages is the English message catalog. Keys are the FieldError
+// codes, with a suffix after '.' naming the variant when a code has several
+// messages; values are fmt format strings.
+var defaultMessages = map[string]string{
+	"malicious_content":           "potentially malicious content detected",
+	"unexpected_field":            "unexpected field '%s'",
+	"invalid_field_name":          "invalid field name",
+	"required":                    "required field is missing",
+	"forbidden":                   "field must not be provided",
+	"exactly_one":                 "exactly one of [%s] must be provided",
+	"out_of_range":                "field '%s' must be between %g and %g",
+	"invalid_format":              "Invalid format for value '%v'",
+	"too_short":                   "field '%s' is shorter than minimum length",
+	"too_long":                    "field '%s' exceeds maximum length",
+	"too_large":                   "value of %d bytes exceeds the maximum size of %d bytes",
+	"invalid_enum":                "invalid value for '%s'",
+	"invalid_mobile":              "invalid mobile number format",
+	"invalid_pan":                 "invalid PAN format",
+	"invalid_email":               "invalid email format",
+	"invalid_id":                  "invalid ID format, should be alphanumeric",
+	"invalid_id.empty":            "ID must not be empty",
+	"invalid_id.length":           "ID must be at least %d characters long",
+	"invalid_otp":                 "invalid OTP format",
+	"invalid_aadhaar":             "invalid Aadhaar number format",
+	"invalid_ifsc":                "invalid IFSC code format",
+	"invalid_hsn":                 "invalid HSN code",
+	"invalid_sac":                 "invalid SAC code",
+	"invalid_gstin":               "invalid GSTIN format",
+	"invalid_date":                "invalid date format",
+	"invalid_duration":            "invalid duration format",
+	"invalid_datetime":            "invalid datetime format",
+	"invalid_semver":              "invalid semantic version",
+	"invalid_semver.need_pre":     "semantic version must have a pre-release part",
+	"invalid_semver.no_pre":       "semantic version must not have a pre-release part",
+	"invalid_semver.need_build":   "semantic version must have build metadata",
+	"invalid_semver.no_build":     "semantic version must not have build metadata",
+	"invalid_url":                 "invalid URL format",
+	"disallowed_url_scheme":       "URL scheme '%s' is not allowed",
+	"invalid_url.scheme":          "invalid URL format, scheme must be http or https",
+	"invalid_url.host":            "invalid URL format, missing host",
+	"invalid_uuid":                "invalid UUID format",
+	"invalid_uuid.version":        "invalid UUID format, expected version %d",
+	"invalid_pincode":             "invalid PIN code format",
+	"invalid_upi":                 "invalid UPI ID",
+	"invalid_card":                "invalid card number format",
+	"invalid_amount":              "invalid amount format",
+	"invalid_amount.negative":     "amount must not be negative",
+	"invalid_amount.decimals":     "amount must have at most %d decimal places",
+	"invalid_amount.max":          "amount must not exceed %v",
+	"invalid_percentage":          "invalid percentage",
+	"invalid_percentage.negative": "percentage must not be negative",
+	"invalid_percentage.range":    "percentage must be between 0 and 100",
+	"weak_password.length":        "password must be at least %d characters long",
+	"weak_password.upper":         "password must contain at least one uppercase letter",
+	"weak_password.lower":         "password must contain at least one lowercase letter",
+	"weak_password.digit":         "password must contain at least one digit",
+	"weak_password.symbol":        "password must contain at least one symbol",
+	"invalid_country":             "invalid country code",
+	"invalid_currency":            "invalid currency code",
+	"invalid_vehicle_number":      "invalid vehicle registration number",
+	"invalid_iban":                "invalid IBAN format",
+	"invalid_iban.checksum":       "invalid IBAN checksum",
+	"invalid_driving_license":     "invalid driving licence number",
+	"invalid_passport":            "invalid passport number format",
+	"invalid_base64":              "invalid base64 format",
+	"invalid_hex":                 "invalid hex format",
+	"invalid_boolean":             "invalid boolean value",
+	"invalid_latitude":            "invalid latitude",
+	"invalid_latitude.range":      "latitude out of range",
+	"invalid_longitude":           "invalid longitude",
+	"invalid_longitude.range":     "longitude out of range",
+	"invalid_file.name":           "invalid file name",
+	"invalid_file.extension":      "file extension '%s' is not allowed",
+	"invalid_file.no_extension":   "file name has no extension",
+	"invalid_file.type":           "file type '%s' is not allowed",
+	"invalid_file.size":           "file exceeds the maximum size of %d bytes",
+	"invalid_type.string":         "field '%s' must be a string",
+	"invalid_type.number":         "field '%s' must be a number",
+	"invalid_type.bool":           "field '%s' must be a boolean",
+	"invalid_type.array":          "field '%s' must be an array",
+	"invalid_type.object":         "field '%s' must be an object",
+	"invalid_type.range":          "field '%s' is out of range for its type",
+}
+
+// DefaultMessages returns a copy of the English message catalog, listing the
+// message keys a translation can provide.
+func DefaultMessages() map[string]string {
+	messages := make(map[string]string, len(defaultMessages))
+	for key, message := range defaultMessages {
+		messages[key] = message
+	}
+	return messages
+}
+
+// messageCatalogs holds the translations added with RegisterMessages, keyed by locale
+var messageCatalogs = map[string]map[string]string{}
+
+// RegisterMessages adds the translations for locale, e.g. "hi" or "fr-CA",
+// keyed like DefaultMessages. Each translation is a format string receiving
+// the same arguments as its English message, in the same order; use explicit
+// indexes such as %[2]s to reorder them. Keys without a translation keep the
+// English message. Registering a locale again merges into its translations.
+func RegisterMessages(locale string, messages map[string]string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	locale = strings.ToLower(locale)
+	catalog := messageCatalogs[locale]
+	if catalog == nil {
+		catalog = make(map[string]string, len(messages))
+		messageCatalogs[locale] = catalog
+	}
+	for key, message := range messages {
+		catalog[key] = message
+	}
+}
+
+// codedError is returned by the validate functions so the failure code can be
+// reported alongside the message, and the message rendered in another locale
+type codedError struct {
+	code string
+	key  string
+	args []interface{}
+}
+
+func (e *codedError) Error() string {
+	return fmt.Sprintf(defaultMessages[e.key], e.args...)
+}
+
+// messageError returns an error carrying the message key and the arguments of
+// its message; the code is the key without its variant suffix
+func messageError(key string, args ...interface{}) error {
+	code, _, _ := strings.Cut(key, ".")
+	return &codedError{code: code, key: key, args: args}
+}
+
+// Locale returns the locale validation messages are rendered in: cfg.Locale
+// when set, and otherwise the most preferred language of an Accept-Language
+// header, by q-value, with registered translations or English. Languages with
+// q=0 are never chosen. It returns "" for English.
+func Locale(cfg Config, acceptLanguage string) string {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if cfg.Locale != "" {
+		return strings.ToLower(cfg.Locale)
+	}
+	for _, tag := range preferredLanguages(acceptLanguage) {
+		if _, ok := messageCatalogs[tag]; ok {
+			return tag
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		if _, ok := messageCatalogs[primary]; ok {
+			return primary
+		}
+		if primary == "en" {
+			return ""
+		}
+	}
+	return ""
+}
+
+// preferredLanguages returns the lower-case language tags of an
+// Accept-Language header by decreasing q-value, in header order for equal
+// values. Tags with q=0 or a malformed q-value are left out.
+func preferredLanguages(acceptLanguage string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+		if q > 0 {
+			languages = append(languages, language{tag, q})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].q > languages[j].q })
+	tags := make([]string, len(languages))
+	for i, lang := range languages {
+		tags[i] = lang.tag
+	}
+	return tags
+}
+
+// LocalizeErrors returns validationErrors with their messages rendered in
+// locale, as returned by Locale. Messages without a translation, and errors
+// returned by custom validators, are left in English.
+func LocalizeErrors(validationErrors []FieldError, locale string) []FieldError {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	catalog := messageCatalogs[locale]
+	if catalog == nil {
+		return validationErrors
+	}
+	localized := make([]FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		localized[i] = fieldErr
+		var coded *codedError
+		if !errors.As(fieldErr.err, &coded) {
+			continue
+		}
+		if format, ok := catalog[coded.key]; ok {
+			localized[i].Message = fmt.Sprintf(format, coded.args...)
+		}
+	}
+	return localized
+}
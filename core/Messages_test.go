@@ -0,0 +1,33 @@
+package core
+
+import "testing"
+
+func TestLocale(t *testing.T) {
+	RegisterMessages("hi", map[string]string{"required": "आवश्यक फ़ील्ड अनुपस्थित है"})
+	RegisterMessages("fr", map[string]string{"required": "champ obligatoire manquant"})
+
+	tests := []struct {
+		name           string
+		cfg            Config
+		acceptLanguage string
+		want           string
+	}{
+		{"q-values", Config{}, "fr;q=0.1, hi;q=0.9", "hi"},
+		{"implicit q=1", Config{}, "fr;q=0.5, hi", "hi"},
+		{"header order on ties", Config{}, "fr, hi", "fr"},
+		{"region fallback", Config{}, "hi-IN;q=0.8, fr;q=0.2", "hi"},
+		{"q=0 excluded", Config{}, "hi;q=0, fr;q=0.1", "fr"},
+		{"English preferred", Config{}, "en-GB, hi;q=0.9", ""},
+		{"unregistered skipped", Config{}, "de, fr;q=0.3", "fr"},
+		{"malformed q excluded", Config{}, "hi;q=abc, fr;q=0.2", "fr"},
+		{"no header", Config{}, "", ""},
+		{"config locale", Config{Locale: "FR"}, "hi", "fr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Locale(tt.cfg, tt.acceptLanguage); got != tt.want {
+				t.Errorf("Locale(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// MultipartConfig lists the files accepted in multipart uploads. Only the
+// part headers are inspected, never the file contents. Empty lists and a zero
+// MaxPartSize accept any value.
+type MultipartConfig struct {
+	// AllowedExtensions lists the accepted file name extensions, e.g.
+	// ".pdf" or "png", compared case-insensitively. Files without an
+	// extension are rejected when the list is set.
+	AllowedExtensions []string
+	// AllowedTypes lists the accepted declared Content-Types of file parts,
+	// e.g. "application/pdf", or "image/*" for every image type
+	AllowedTypes []string
+	// MaxPartSize is the maximum size of one file in bytes
+	MaxPartSize int64
+}
+
+// ValidateMultipartFiles checks the file parts of a parsed multipart form,
+// such as http.Request.MultipartForm.File, against mc. Errors are reported
+// against the form field name, with an index when a field holds several files.
+func ValidateMultipartFiles(mc MultipartConfig, files map[string][]*multipart.FileHeader) []FieldError {
+	var validationErrors []FieldError
+	for _, key := range sortedKeys(files) {
+		for i, file := range files[key] {
+			path := key
+			if len(files[key]) > 1 {
+				path = fmt.Sprintf("%s[%d]", key, i)
+			}
+			if err := mc.validateFile(file); err != nil {
+				addValidationError(&validationErrors, path, err)
+			}
+		}
+	}
+	return validationErrors
+}
+
+// validateFile checks the name, declared type and size of one file part
+func (mc MultipartConfig) validateFile(file *multipart.FileHeader) error {
+	if file.Filename == "" || strings.ContainsFunc(file.Filename, unicode.IsControl) {
+		return messageError("invalid_file.name")
+	}
+	if len(mc.AllowedExtensions) > 0 {
+		ext := strings.ToLower(filepath.Ext(file.Filename))
+		if ext == "" {
+			return messageError("invalid_file.no_extension")
+		}
+		if !mc.extensionAllowed(ext) {
+			return messageError("invalid_file.extension", ext)
+		}
+	}
+	if len(mc.AllowedTypes) > 0 {
+		declared := mediaType(file.Header.Get("Content-Type"))
+		if !mc.typeAllowed(declared) {
+			return messageError("invalid_file.type", declared)
+		}
+	}
+	if mc.MaxPartSize > 0 && file.Size > mc.MaxPartSize {
+		return messageError("invalid_file.size", mc.MaxPartSize)
+	}
+	return nil
+}
+
+// extensionAllowed reports whether ext, lower case with its dot, is in AllowedExtensions
+func (mc MultipartConfig) extensionAllowed(ext string) bool {
+	for _, allowed := range mc.AllowedExtensions {
+		if strings.EqualFold("."+strings.TrimPrefix(allowed, "."), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeAllowed reports whether the media type declared matches AllowedTypes
+func (mc MultipartConfig) typeAllowed(declared string) bool {
+	if declared == "" {
+		return false
+	}
+	for _, allowed := range mc.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(declared, prefix+"/") {
+				return true
+			}
+		} else if declared == allowed {
+			return true
+		}
+	}
+	return false
+}
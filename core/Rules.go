@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesFile is the layout of a file read by LoadRules
+type rulesFile struct {
+	// Required lists dotted paths as accepted by RegisterRequiredFields
+	Required []string `json:"required" yaml:"required"`
+	// Fields holds the rules of fields, keyed by field name
+	Fields map[string]fieldRule `json:"fields" yaml:"fields"`
+}
+
+// fieldRule is the rule of one field in a rules file; every entry is optional
+type fieldRule struct {
+	Type       FieldType `json:"type" yaml:"type"`
+	Pattern    string    `json:"pattern" yaml:"pattern"`
+	Message    string    `json:"message" yaml:"message"`
+	MinLength  int       `json:"min_length" yaml:"min_length"`
+	MaxLength  int       `json:"max_length" yaml:"max_length"`
+	Enum       []string  `json:"enum" yaml:"enum"`
+	IgnoreCase bool      `json:"ignore_case" yaml:"ignore_case"`
+	Min        *float64  `json:"min" yaml:"min"`
+	Max        *float64  `json:"max" yaml:"max"`
+}
+
+// LoadRules registers the rules described by the JSON or, for .yaml and .yml
+// files, YAML file at path, for deployments configuring the validator
+// without code:
+//
+//	{
+//	  "required": ["mobile", "user.email"],
+//	  "fields": {
+//	    "mobile": {"type": "string", "min_length": 10, "max_length": 10},
+//	    "sku":    {"pattern": "^[A-Z]{3}-[0-9]{4}$", "message": "invalid SKU"},
+//	    "status": {"enum": ["active", "blocked"], "ignore_case": true},
+//	    "age":    {"type": "number", "min": 18, "max": 120}
+//	  }
+//	}
+//
+// Each entry maps to the matching setter: type to SetExpectedType, pattern
+// and message to RegisterRegexValidator, min_length and max_length to
+// SetFieldLength, enum to SetAllowedValues and min and max, which go
+// together, to SetNumericRange. The whole file is checked before anything is
+// registered, so a malformed file returns an error naming the offending entry
+// and leaves the rules unchanged.
+func LoadRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var rules rulesFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&rules)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&rules)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, key := range sortedKeys(rules.Fields) {
+		if err := rules.Fields[key].check(); err != nil {
+			return fmt.Errorf("%s: field %q: %w", path, key, err)
+		}
+	}
+	for _, field := range rules.Required {
+		if field == "" {
+			return fmt.Errorf("%s: empty required field path", path)
+		}
+	}
+
+	if len(rules.Required) > 0 {
+		RegisterRequiredFields(rules.Required)
+	}
+	for key, rule := range rules.Fields {
+		rule.register(key)
+	}
+	return nil
+}
+
+// check reports the first malformed entry of the rule
+func (rule fieldRule) check() error {
+	switch rule.Type {
+	case "", TypeString, TypeNumber, TypeBool, TypeArray, TypeObject:
+	default:
+		return fmt.Errorf("unknown type %q", rule.Type)
+	}
+	if rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	} else if rule.Message != "" {
+		return errors.New("message without a pattern")
+	}
+	if rule.MinLength < 0 || rule.MaxLength < 0 || rule.MaxLength > 0 && rule.MaxLength < rule.MinLength {
+		return fmt.Errorf("invalid length range %d-%d", rule.MinLength, rule.MaxLength)
+	}
+	if rule.IgnoreCase && len(rule.Enum) == 0 {
+		return errors.New("ignore_case without an enum")
+	}
+	if (rule.Min == nil) != (rule.Max == nil) {
+		return errors.New("min and max must be given together")
+	}
+	if rule.Min != nil && *rule.Min > *rule.Max {
+		return fmt.Errorf("invalid range %g-%g", *rule.Min, *rule.Max)
+	}
+	return nil
+}
+
+// register applies a checked rule to fields named key
+func (rule fieldRule) register(key string) {
+	if rule.Type != "" {
+		SetExpectedType(key, rule.Type)
+	}
+	if rule.Pattern != "" {
+		message := rule.Message
+		if message == "" {
+			message = fmt.Sprintf("invalid format for '%s'", key)
+		}
+		RegisterRegexValidator(key, rule.Pattern, message)
+	}
+	if rule.MinLength > 0 || rule.MaxLength > 0 {
+		SetFieldLength(key, rule.MinLength, rule.MaxLength)
+	}
+	if len(rule.Enum) > 0 {
+		if rule.IgnoreCase {
+			SetAllowedValuesIgnoreCase(key, rule.Enum)
+		} else {
+			SetAllowedValues(key, rule.Enum)
+		}
+	}
+	if rule.Min != nil {
+		SetNumericRange(key, *rule.Min, *rule.Max)
+	}
+}
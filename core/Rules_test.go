@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRules writes a rules file named name holding content to a temporary
+// directory and returns its path
+func writeRules(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// restoreRequiredFields restores the package-wide required fields when a
+// test registering some ends
+func restoreRequiredFields(t *testing.T) {
+	rulesMu.RLock()
+	saved := requiredFields
+	rulesMu.RUnlock()
+	t.Cleanup(func() {
+		rulesMu.Lock()
+		requiredFields = saved
+		rulesMu.Unlock()
+	})
+}
+
+func TestLoadRules(t *testing.T) {
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"rules.json", `{
+			"required": ["json_required"],
+			"fields": {
+				"json_sku": {"pattern": "^[A-Z]{3}-[0-9]{4}$", "message": "invalid SKU"},
+				"json_code": {"type": "string", "min_length": 2, "max_length": 4},
+				"json_status": {"enum": ["active", "blocked"], "ignore_case": true},
+				"json_age": {"type": "number", "min": 18, "max": 120}
+			}
+		}`},
+		{"rules.yaml", `
+required: [yaml_required]
+fields:
+  yaml_sku:
+    pattern: "^[A-Z]{3}-[0-9]{4}$"
+    message: invalid SKU
+  yaml_code: {type: string, min_length: 2, max_length: 4}
+  yaml_status: {enum: [active, blocked], ignore_case: true}
+  yaml_age: {type: number, min: 18, max: 120}
+`},
+	}
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			restoreRequiredFields(t)
+			if err := LoadRules(writeRules(t, file.name, file.content)); err != nil {
+				t.Fatalf("LoadRules() = %v", err)
+			}
+			prefix := strings.TrimPrefix(filepath.Ext(file.name), ".") + "_"
+
+			tests := []struct {
+				key     string
+				value   interface{}
+				wantErr bool
+			}{
+				{"sku", "ABC-1234", false},
+				{"sku", "abc-1234", true},
+				{"code", "AB", false},
+				{"code", "A", true},
+				{"code", "ABCDE", true},
+				{"code", 12, true},
+				{"status", "Active", false},
+				{"status", "deleted", true},
+				{"age", 18, false},
+				{"age", 121, true},
+				{"age", "42", true},
+			}
+			for _, tt := range tests {
+				document := map[string]interface{}{prefix + "required": "x", prefix + tt.key: tt.value}
+				validationErrors, err := validateData(context.Background(), Config{}, document)
+				if err != nil {
+					t.Fatal(err)
+				}
+				messages := errorStrings(validationErrors)
+				if gotErr := len(messages) > 0; gotErr != tt.wantErr {
+					t.Errorf("%s%s=%v: errors %q, want errors %v", prefix, tt.key, tt.value, messages, tt.wantErr)
+				}
+			}
+
+			validationErrors, err := validateData(context.Background(), Config{}, map[string]interface{}{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fields := fieldNames(validationErrors); !strings.Contains(fields, prefix+"required") {
+				t.Errorf("empty document failed fields %q, want %srequired", fields, prefix)
+			}
+		})
+	}
+}
+
+func TestLoadRulesRejectsMalformedFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"unknown json field", "rules.json", `{"fields": {"malformed_sku": {"patern": "^[A-Z]+$"}}}`, `unknown field "patern"`},
+		{"unknown yaml field", "rules.yml", "fields:\n  malformed_sku:\n    patern: '^[A-Z]+$'\n", "field patern not found"},
+		{"unknown top-level field", "rules.json", `{"require": ["malformed_required"]}`, `unknown field "require"`},
+		{"invalid json", "rules.json", `{"fields": {`, "unexpected EOF"},
+		{"invalid yaml", "rules.yaml", "fields: [", "yaml"},
+		{"unknown type", "rules.json", `{"fields": {"malformed_age": {"type": "integer"}}}`, `field "malformed_age": unknown type "integer"`},
+		{"invalid pattern", "rules.json", `{"fields": {"malformed_sku": {"pattern": "[A-Z"}}}`, `field "malformed_sku": invalid pattern`},
+		{"message without pattern", "rules.json", `{"fields": {"malformed_sku": {"message": "invalid SKU"}}}`, "message without a pattern"},
+		{"inverted lengths", "rules.json", `{"fields": {"malformed_code": {"min_length": 4, "max_length": 2}}}`, "invalid length range 4-2"},
+		{"min without max", "rules.yaml", "fields:\n  malformed_age: {min: 18}\n", "min and max must be given together"},
+		{"inverted range", "rules.json", `{"fields": {"malformed_age": {"min": 120, "max": 18}}}`, "invalid range 120-18"},
+		{"ignore case without enum", "rules.json", `{"fields": {"malformed_status": {"ignore_case": true}}}`, "ignore_case without an enum"},
+		{"empty required path", "rules.json", `{"required": [""]}`, "empty required field path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LoadRules(writeRules(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadRules() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A malformed entry after valid ones leaves the valid ones unregistered
+	restoreRequiredFields(t)
+	path := writeRules(t, "rules.json", `{
+		"required": ["malformed_required"],
+		"fields": {
+			"malformed_a": {"pattern": "^x$"},
+			"malformed_b": {"type": "integer"},
+			"malformed_c": {"min_length": 5}
+		}
+	}`)
+	if err := LoadRules(path); err == nil {
+		t.Fatal("LoadRules() = nil, want an error")
+	}
+	for _, key := range []string{"malformed_a", "malformed_c"} {
+		if messages := validateOne(t, Config{}, key, "abc"); len(messages) > 0 {
+			t.Errorf("%s got rules from a rejected file: %q", key, messages)
+		}
+	}
+	validationErrors, err := validateData(context.Background(), Config{}, map[string]interface{}{})
+	if err != nil || len(validationErrors) > 0 {
+		t.Errorf("rejected file registered required fields: %v, %v", validationErrors, err)
+	}
+}
+
+// fieldNames joins the fields of validationErrors
+func fieldNames(validationErrors []FieldError) string {
+	fields := make([]string, len(validationErrors))
+	for i, validationError := range validationErrors {
+		fields[i] = validationError.Field
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FieldType is the JSON type expected of a field
+type FieldType string
+
+const (
+	TypeString FieldType = "string"
+	TypeNumber FieldType = "number"
+	TypeBool   FieldType = "bool"
+	TypeArray  FieldType = "array"
+	TypeObject FieldType = "object"
+)
+
+// validateFieldType checks that a decoded value has type t; an empty t accepts any type
+func validateFieldType(key string, value interface{}, t FieldType) error {
+	var ok bool
+	switch t {
+	case "":
+		return nil
+	case TypeString:
+		_, ok = value.(string)
+	case TypeNumber:
+		_, ok = toFloat64(value)
+	case TypeBool:
+		_, ok = value.(bool)
+	case TypeArray:
+		_, ok = value.([]interface{})
+	case TypeObject:
+		_, ok = value.(map[string]interface{})
+	}
+	if !ok {
+		return messageError("invalid_type."+string(t), key)
+	}
+	return nil
+}
+
+// SchemaField is the rule for one field of a Schema
+type SchemaField struct {
+	// Required reports the field as missing when it is absent or null
+	Required bool
+	// Type is the expected JSON type; empty accepts any type
+	Type FieldType
+	// Format names the field rules the value is checked with, e.g. "mobile"
+	// or "email": the value is validated as if its key were Format, including
+	// validators added with RegisterValidator. Empty applies no format.
+	Format string
+}
+
+// Schema describes the fields accepted by one endpoint, keyed by dotted path
+// without array indexes, e.g. "user.mobile" or "items.sku". A body validated
+// against a schema is checked only by the schema, not by the key-based rules.
+// Fields missing from the schema are reported as unexpected in the objects
+// the schema describes: the top level and any object with an entry below it.
+type Schema map[string]SchemaField
+
+// ValidateBodyWithSchema is ValidateBody for a body checked against schema.
+// Form values are strings, so form fields should use TypeString or no Type.
+func ValidateBodyWithSchema(ctx context.Context, cfg Config, schema Schema, contentType string, body []byte) (interface{}, []FieldError, error) {
+	if err := checkContentType(cfg, contentType, body); err != nil {
+		return nil, nil, err
+	}
+	if parsesAsForm(contentType) {
+		values, err := parseFormBody(contentType, body)
+		if err != nil {
+			return nil, nil, ErrMalformedForm
+		}
+		validationErrors, err := schema.validate(ctx, cfg, trimData(cfg, formData(values)), false)
+		return nil, validationErrors, err
+	}
+
+	jsonData, err := decodeDocument(cfg, contentType, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	sanitizeData(jsonData)
+	validated := trimData(cfg, jsonData)
+	validationErrors, err := schema.validate(ctx, cfg, validated, false)
+	return storedData(cfg, jsonData, validated), validationErrors, err
+}
+
+// formData converts form values to a JSON-like object, with repeated keys as arrays
+func formData(values map[string][]string) map[string]interface{} {
+	data := make(map[string]interface{}, len(values))
+	for key, list := range values {
+		if len(list) == 1 {
+			data[key] = list[0]
+			continue
+		}
+		items := make([]interface{}, len(list))
+		for i, value := range list {
+			items[i] = value
+		}
+		data[key] = items
+	}
+	return data
+}
+
+// validate walks data against the schema and then checks its required fields.
+// A derived schema, built from struct tags, gives fields missing from it the
+// key-based rules instead of reporting them as unexpected, and requires its
+// nested fields only in the objects present, as in every array element.
+func (s Schema) validate(ctx context.Context, cfg Config, data interface{}, derived bool) ([]FieldError, error) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	parents := map[string]bool{"": true}
+	for path := range s {
+		for i := len(path) - 1; i > 0; i-- {
+			if path[i] == '.' {
+				parents[path[:i]] = true
+			}
+		}
+	}
+
+	var validationErrors []FieldError
+	if err := s.walk(ctx, cfg, "", data, 0, parents, derived, &validationErrors); err != nil {
+		return validationErrors, err
+	}
+	for _, path := range sortedKeys(s) {
+		switch {
+		case !s[path].Required:
+		case derived:
+			for _, missing := range missingBelowPresent(data, "", strings.Split(path, ".")) {
+				addValidationError(&validationErrors, missing, messageError("required"))
+			}
+		case lookupPath(data, path) == nil:
+			addValidationError(&validationErrors, path, messageError("required"))
+		}
+	}
+	return validationErrors, nil
+}
+
+// walk checks the value found at path, and the fields below it, against the
+// schema. depth is the number of enclosing objects and arrays, as in validateNested.
+func (s Schema) walk(ctx context.Context, cfg Config, path string, input interface{}, depth int, parents map[string]bool, derived bool, validationErrors *[]FieldError) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch v := input.(type) {
+	case map[string]interface{}:
+		if maxDepth > 0 && depth >= maxDepth {
+			return ErrTooDeeplyNested
+		}
+		parent := arrayIndexRegex.ReplaceAllString(path, "")
+		for _, key := range sortedKeys(v) {
+			fieldPath := joinPath(path, key)
+			if err := validateFieldName(key); err != nil {
+				addValidationError(validationErrors, joinPath(path, printableKey(key)), err)
+				continue
+			}
+			field, ok := s[joinPath(parent, key)]
+			if !ok && parents[parent] && derived {
+				if err := validateNestedMap(ctx, cfg, path, map[string]interface{}{key: v[key]}, depth, validationErrors); err != nil {
+					return err
+				}
+				continue
+			}
+			if !ok && parents[parent] {
+				addValidationError(validationErrors, fieldPath, messageError("unexpected_field", key))
+				continue
+			}
+			if v[key] == nil {
+				continue
+			}
+			if err := validateStringSize(v[key]); err != nil {
+				addValidationError(validationErrors, fieldPath, err)
+				continue
+			}
+			if err := validateFieldType(key, v[key], field.Type); err != nil {
+				addValidationError(validationErrors, fieldPath, err)
+				continue
+			}
+			if err := s.walk(ctx, cfg, fieldPath, v[key], depth+1, parents, derived, validationErrors); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if maxDepth > 0 && depth >= maxDepth {
+			return ErrTooDeeplyNested
+		}
+		for i, item := range v {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := validateStringSize(item); err != nil {
+				addValidationError(validationErrors, itemPath, err)
+				continue
+			}
+			if err := s.walk(ctx, cfg, itemPath, item, depth+1, parents, derived, validationErrors); err != nil {
+				return err
+			}
+		}
+	case string, json.Number:
+		// Scalars, including the elements of scalar arrays, get the format
+		// of the field they belong to
+		if field := s[arrayIndexRegex.ReplaceAllString(path, "")]; field.Format != "" {
+			validateField(cfg, field.Format, path, getStringValue(v), validationErrors)
+		}
+	}
+	return nil
+}
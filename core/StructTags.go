@@ -0,0 +1,298 @@
+package core
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// schemaTypes holds the schemas derived from struct types, keyed by struct type
+var schemaTypes = map[reflect.Type]Schema{}
+
+// RegisterSchemaType derives the schema of the struct type t, or of the
+// struct t points to, from its `validate` tags, so malformed tags are
+// reported at startup rather than by the first request:
+//
+//	type Signup struct {
+//		Mobile  string   `json:"mobile" validate:"mobile,required"`
+//		Email   string   `json:"email" validate:"email"`
+//		Address *Address `json:"address"`
+//	}
+//
+// A tag lists "required" and at most one format, used as SchemaField.Format:
+// a built-in field name such as "email", a name made a pincode, base64, hex
+// or boolean key, an ID key such as "user_id", or a key given to
+// RegisterValidator before the type is registered. Config.Validators are not
+// known when tags are parsed, so their keys cannot be used as formats.
+// Fields are named and nested as encoding/json would decode them: by their
+// json tag or Go name, skipping unexported fields and those tagged json:"-",
+// with the fields of embedded structs promoted. Their Go type gives the
+// expected JSON type, except for types decoding themselves, such as
+// time.Time, which accept any type. Calling ValidateBodyInto with an
+// unregistered type registers it.
+func RegisterSchemaType(t reflect.Type) error {
+	_, err := schemaForType(t)
+	return err
+}
+
+// schemaForType returns the schema of the struct type t or *t, deriving it
+// on first use
+func schemaForType(t reflect.Type) (Schema, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema type must be a struct, got %v", t)
+	}
+	rulesMu.RLock()
+	schema, ok := schemaTypes[t]
+	rulesMu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema = Schema{}
+	if err := addStructFields(schema, "", t, map[reflect.Type]bool{}); err != nil {
+		return nil, fmt.Errorf("%v: %w", t, err)
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	schemaTypes[t] = schema
+	return schema, nil
+}
+
+// addStructFields adds the fields of the struct type t to schema under path.
+// visiting holds the struct types being added, so recursive types stop at the
+// first repetition.
+func addStructFields(schema Schema, path string, t reflect.Type, visiting map[reflect.Type]bool) error {
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if err := addStructFields(schema, path, fieldType, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		rule, err := parseValidateTag(field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		fieldPath := joinPath(path, name)
+		rule.Type = jsonType(fieldType)
+		schema[fieldPath] = rule
+
+		// Struct fields, and the struct elements of arrays, share the path
+		// of their parent since schema paths have no array indexes
+		elem := fieldType
+		if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			elem = elem.Elem()
+			for elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+		}
+		if elem.Kind() == reflect.Struct && !decodesItself(elem) && !visiting[elem] {
+			if err := addStructFields(schema, fieldPath, elem, visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseValidateTag converts a `validate` tag such as "mobile,required" to a schema field
+func parseValidateTag(tag string) (SchemaField, error) {
+	var rule SchemaField
+	if tag == "" {
+		return rule, nil
+	}
+	for _, option := range strings.Split(tag, ",") {
+		switch option = strings.TrimSpace(option); {
+		case option == "required":
+			rule.Required = true
+		case option == "":
+			return rule, errors.New("empty validate option")
+		case rule.Format != "":
+			return rule, fmt.Errorf("more than one format in validate tag %q", tag)
+		case !isKnownFormat(option):
+			return rule, fmt.Errorf("unknown format %q in validate tag %q", option, tag)
+		default:
+			rule.Format = option
+		}
+	}
+	return rule, nil
+}
+
+// isKnownFormat reports whether format names field rules, as listed for
+// RegisterSchemaType
+func isKnownFormat(format string) bool {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if _, ok := customValidators[format]; ok {
+		return true
+	}
+	name := builtinKey(format)
+	return builtinFormats[name] || pincodeKeys[name] || base64Keys[name] || hexKeys[name] ||
+		booleanKeys[name] || idKeyRegex.MatchString(format)
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodesItself reports whether values of t implement their own decoding
+func decodesItself(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(jsonUnmarshalerType) || p.Implements(textUnmarshalerType)
+}
+
+// jsonType returns the JSON type encoding/json decodes into t, or "" for any
+func jsonType(t reflect.Type) FieldType {
+	if decodesItself(t) {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Bool:
+		return TypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return TypeNumber
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is decoded from a base64 string
+			return TypeString
+		}
+		return TypeArray
+	case reflect.Array:
+		return TypeArray
+	case reflect.Struct, reflect.Map:
+		return TypeObject
+	default:
+		return ""
+	}
+}
+
+// missingBelowPresent returns the concrete paths at which the path made of
+// segments is absent or null below current, found at prefix. The path is only
+// checked in the objects present, and in every element of the arrays on the
+// way, as suits the optional nested structs of a derived schema.
+func missingBelowPresent(current interface{}, prefix string, segments []string) []string {
+	if items, ok := current.([]interface{}); ok && prefix != "" {
+		var missing []string
+		for i, item := range items {
+			missing = append(missing, missingBelowPresent(item, fmt.Sprintf("%s[%d]", prefix, i), segments)...)
+		}
+		return missing
+	}
+	if current == nil && prefix == "" {
+		// An empty body misses every required top-level field
+		current = map[string]interface{}{}
+	}
+	object, ok := current.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	value, path := object[segments[0]], joinPath(prefix, segments[0])
+	if len(segments) == 1 {
+		if value == nil {
+			return []string{path}
+		}
+		return nil
+	}
+	return missingBelowPresent(value, path, segments[1:])
+}
+
+// ValidateBodyInto is ValidateBodyWithSchema for the schema derived from the
+// type of target, a pointer to a struct, as described for
+// RegisterSchemaType. Fields missing from the struct get the key-based rules
+// instead of being rejected. When a JSON body passes, it is decoded into
+// target, after sanitizing and, with StoreTrimmed, trimming; a value the
+// struct cannot hold, such as 300 for a uint8, is reported as a field error.
+// Form bodies are validated without the types of the Go fields and are not
+// decoded.
+func ValidateBodyInto(ctx context.Context, cfg Config, target interface{}, contentType string, body []byte) (interface{}, []FieldError, error) {
+	if t := reflect.TypeOf(target); t == nil || t.Kind() != reflect.Pointer {
+		return nil, nil, fmt.Errorf("ValidateBodyInto target must be a pointer to a struct, got %T", target)
+	}
+	schema, err := schemaForType(reflect.TypeOf(target))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkContentType(cfg, contentType, body); err != nil {
+		return nil, nil, err
+	}
+	if parsesAsForm(contentType) {
+		values, err := parseFormBody(contentType, body)
+		if err != nil {
+			return nil, nil, ErrMalformedForm
+		}
+		// Form values are all strings, so the types of the Go fields do not apply
+		untyped := make(Schema, len(schema))
+		for path, field := range schema {
+			field.Type = ""
+			untyped[path] = field
+		}
+		validationErrors, err := untyped.validate(ctx, cfg, trimData(cfg, formData(values)), true)
+		return nil, validationErrors, err
+	}
+
+	jsonData, err := decodeDocument(cfg, contentType, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	sanitizeData(jsonData)
+	validated := trimData(cfg, jsonData)
+	validationErrors, err := schema.validate(ctx, cfg, validated, true)
+	stored := storedData(cfg, jsonData, validated)
+	if err != nil || len(validationErrors) > 0 || stored == nil {
+		return stored, validationErrors, err
+	}
+	return stored, decodeInto(stored, target), nil
+}
+
+// decodeInto decodes the validated data into target, reporting values the
+// struct cannot hold as field errors
+func decodeInto(data interface{}, target interface{}) []FieldError {
+	encoded, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(encoded, target)
+	}
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr):
+		var validationErrors []FieldError
+		key := typeErr.Field[strings.LastIndexByte(typeErr.Field, '.')+1:]
+		addValidationError(&validationErrors, typeErr.Field, messageError("invalid_type.range", key))
+		return validationErrors
+	default:
+		var validationErrors []FieldError
+		addValidationError(&validationErrors, "", err)
+		return validationErrors
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValidateTag(t *testing.T) {
+	RegisterValidator("tags_test_nickname", func(value string) error { return nil })
+
+	tests := []struct {
+		tag     string
+		want    SchemaField
+		wantErr string
+	}{
+		{tag: "", want: SchemaField{}},
+		{tag: "required", want: SchemaField{Required: true}},
+		{tag: "mobile,required", want: SchemaField{Format: "mobile", Required: true}},
+		{tag: "email", want: SchemaField{Format: "email"}},
+		{tag: "phone_number", want: SchemaField{Format: "phone_number"}},
+		{tag: "user_id", want: SchemaField{Format: "user_id"}},
+		{tag: "tags_test_nickname", want: SchemaField{Format: "tags_test_nickname"}},
+		{tag: "emial", wantErr: `unknown format "emial"`},
+		{tag: "required,mobiel", wantErr: `unknown format "mobiel"`},
+		{tag: "mobile,email", wantErr: "more than one format"},
+		{tag: "mobile,", wantErr: "empty validate option"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := parseValidateTag(tt.tag)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseValidateTag(%q) error = %v, want %q", tt.tag, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseValidateTag(%q) = %+v, %v, want %+v", tt.tag, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterSchemaTypeRejectsUnknownFormat(t *testing.T) {
+	type signup struct {
+		Email string `json:"email" validate:"emial,required"`
+	}
+	err := RegisterSchemaType(reflect.TypeOf(signup{}))
+	if err == nil || !strings.Contains(err.Error(), "field Email") {
+		t.Errorf("RegisterSchemaType() error = %v, want it to name field Email", err)
+	}
+}
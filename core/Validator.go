@@ -0,0 +1,3420 @@
+// Package core holds the framework independent validation rules and the
+// net/http middleware. It does not import any web framework; the gin
+// middleware in the parent package and the adapters for other frameworks are
+// built on ValidateBody.
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	generalFormatRegex  = regexp.MustCompile(`^[ @/=a-zA-Z0-9\.\-_,:()']*$`)
+	mobileRegex         = regexp.MustCompile(`^[0-9]{10}$`)
+	e164Regex           = regexp.MustCompile(`^\+?[1-9][0-9]{1,14}$`)
+	panRegex            = regexp.MustCompile(`^[A-Z]{5}[0-9]{4}[A-Z]{1}$`)
+	idRegex             = regexp.MustCompile(`^[A-Za-z0-9]*$`)
+	otpRegex            = regexp.MustCompile(`^\d{6}$`)
+	aadhaarRegex        = regexp.MustCompile(`^[2-9][0-9]{11}$`)
+	ifscRegex           = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	gstinSuffixRegex    = regexp.MustCompile(`^[1-9A-Z]Z[0-9A-Z]$`)
+	pincodeRegex        = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+	uuidRegex           = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	vehicleRegex        = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z]{0,2}[0-9]{1,4}$`)
+	ibanRegex           = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	drivingLicenseRegex = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}(19|20)[0-9]{2}[0-9]{7}$`)
+	amountRegex         = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+	passportRegex       = regexp.MustCompile(`^[A-Z][A-Z]?[0-9]{7}$`)
+	upiRegex            = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.\-_]{1,255}@[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+)
+
+// Verhoeff dihedral group multiplication and permutation tables
+var (
+	verhoeffMultiplication = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermutation = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 7, 6, 8, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// Logger is the logging interface used to report validation failures.
+// *logrus.Logger and zap's *SugaredLogger satisfy it.
+type Logger interface {
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// slogLogger is the default Logger, writing to the default log/slog logger
+type slogLogger struct{}
+
+func (slogLogger) Error(args ...interface{}) {
+	slog.Error(fmt.Sprint(args...))
+}
+
+func (slogLogger) Errorf(format string, args ...interface{}) {
+	slog.Error(fmt.Sprintf(format, args...))
+}
+
+// rulesMu guards the rule registries: the Set, Register and Disable functions
+// hold it for writing and validation holds it for reading, so rules can be
+// changed while requests are served. Custom validators, sanitizers and
+// conditional rule predicates run with it held and must not change rules.
+var rulesMu sync.RWMutex
+
+var logger Logger = slogLogger{}
+
+// SetLogger replaces the logger used by the package. Passing nil restores the
+// default, which logs through log/slog.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = slogLogger{}
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	logger = l
+}
+
+// currentLogger returns the logger set with SetLogger
+func currentLogger() Logger {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return logger
+}
+
+type ResponseBody struct {
+	StatusCode int
+	Message    string
+	Body       struct{}
+	Errors     []string     `json:",omitempty"`
+	Details    []FieldError `json:",omitempty"`
+	// problem renders the response as RFC 7807 problem details, set from
+	// Config.ProblemDetails
+	problem bool
+}
+
+// problemDetails is the RFC 7807 form of a ResponseBody
+type problemDetails struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// MarshalJSON encodes the response in the default layout, or as RFC 7807
+// problem details when built with Config.ProblemDetails set
+func (r ResponseBody) MarshalJSON() ([]byte, error) {
+	if !r.problem {
+		type plain ResponseBody
+		return json.Marshal(plain(r))
+	}
+	return json.Marshal(problemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(r.StatusCode),
+		Status: r.StatusCode,
+		Detail: r.Message,
+		Errors: r.Details,
+	})
+}
+
+// ContentType returns the media type of the encoded response,
+// "application/problem+json" for problem details and "application/json"
+// otherwise
+func (r ResponseBody) ContentType() string {
+	if r.problem {
+		return "application/problem+json"
+	}
+	return "application/json"
+}
+
+// FieldError describes one validation failure: the path of the offending
+// field, a stable machine readable code such as "invalid_mobile", and a
+// human readable message.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// err is the error reported by the validator, kept to render Message in
+	// another locale
+	err error
+}
+
+// Error formats the failure as "field: message", the form used in logs and
+// ResponseBody.Errors.
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// errorStrings formats validation errors as "field: message" strings
+func errorStrings(validationErrors []FieldError) []string {
+	if validationErrors == nil {
+		return nil
+	}
+	messages := make([]string, len(validationErrors))
+	for i, err := range validationErrors {
+		messages[i] = err.Error()
+	}
+	return messages
+}
+
+// Config controls the behaviour of the validation middleware.
+type Config struct {
+	// ReturnErrors includes the collected validation errors in the
+	// 422 response body instead of only logging them, both as strings in
+	// Errors and as field, code and message objects in Details.
+	ReturnErrors bool
+	// ProblemDetails formats failure responses as RFC 7807 problem details
+	// with Content-Type application/problem+json: type, title, status, the
+	// message as detail and, with ReturnErrors, the Details objects as an
+	// "errors" member. Errors that prevent reading the body keep the
+	// default format.
+	ProblemDetails bool
+	// MaxBodySize caps the number of body bytes read, in bytes. Zero or a
+	// negative value disables the limit.
+	MaxBodySize int64
+	// ValidationStatus and ValidationMessage are sent when validation fails,
+	// by default 422 and "invalid request".
+	ValidationStatus  int
+	ValidationMessage string
+	// DecodeStatus and DecodeMessage are sent when the body cannot be
+	// decoded, by default 400 and a message naming the body type, e.g.
+	// "malformed JSON body".
+	DecodeStatus  int
+	DecodeMessage string
+	// RequireJSONContentType rejects requests with a body whose Content-Type
+	// is missing or not JSON with 415 Unsupported Media Type. Parameters such
+	// as "; charset=utf-8" are allowed.
+	RequireJSONContentType bool
+	// ReportOnly logs validation errors but lets the request through, for
+	// measuring the impact of the rules before enforcing them. Decoding
+	// failures are still rejected.
+	ReportOnly bool
+	// WarningsHeader, when set in ReportOnly mode, names a response header
+	// such as "X-Validation-Warnings" that carries the errors, separated by "; ".
+	WarningsHeader string
+	// Locale renders the messages of validation errors in a locale added with
+	// RegisterMessages, e.g. "hi". When empty the locale is negotiated from
+	// the Accept-Language header, falling back to English. Logs stay English.
+	Locale string
+	// TrimSpace validates string values with surrounding whitespace removed,
+	// so " 9876543210 " passes the mobile check. Downstream handlers still
+	// get the values as sent unless StoreTrimmed is also set.
+	TrimSpace    bool
+	StoreTrimmed bool
+	// RejectDuplicateKeys rejects JSON bodies repeating a key within an
+	// object with 400 instead of validating the last value only, as
+	// encoding/json would, so {"otp":"000000","otp":"abc"} cannot slip through.
+	RejectDuplicateKeys bool
+	// RejectEmptyBody rejects POST, PUT and PATCH requests without a body
+	// with 400 "empty request body". Other methods, such as GET, may always
+	// omit the body. By default empty bodies pass validation.
+	RejectEmptyBody bool
+	// RequireJSONObject rejects JSON bodies holding an array, a scalar such as
+	// 42 or true, or null with 400 "expected JSON object". By default such
+	// bodies are validated: array elements get the rules of their parent
+	// key, and top-level scalars get the general format check.
+	RequireJSONObject bool
+	// TargetedValidation checks only the fields with a validator of their
+	// own: the built-in ones such as otp, pan and email, and those added with
+	// RegisterValidator, RegisterPathValidator or Validators. Other values
+	// skip the general format check and the ID check of keys such as
+	// "user_id", so free text passes untouched. Length, enum, range and
+	// blocklist rules set for a field still apply.
+	TargetedValidation bool
+	// OnValidationResult, when set, is called once per validated request
+	// with its outcome, e.g. to count failures per route and field.
+	OnValidationResult func(result ValidationResult)
+	// TrustedHeader names a request header, e.g. "X-Internal-Validated",
+	// that skips body validation when set to "true" on a request whose peer
+	// address is in TrustedNetworks, for traffic already validated upstream.
+	// The header is ignored from any other address, and forwarding headers
+	// such as X-Forwarded-For are never consulted.
+	TrustedHeader   string
+	TrustedNetworks []netip.Prefix
+	// StreamFailFast stops ValidateBodyStream at the first array element
+	// with errors, without decoding the rest of the body.
+	StreamFailFast bool
+	// StreamKeepBody keeps the body read by the ValidateStream middleware so
+	// the handler can read it again, holding up to MaxBodySize bytes in memory.
+	// Otherwise the handler reads an empty body.
+	StreamKeepBody bool
+	// MobileMode, RequiredFields and Validators set rules for this
+	// configuration only, so route groups can differ. MobileMode overrides
+	// SetMobileMode when set, RequiredFields are required in addition to those
+	// registered with RegisterRequiredFields, and Validators take precedence
+	// over RegisterValidator and the built-in rules for their keys.
+	MobileMode     MobileMode
+	RequiredFields []string
+	Validators     map[string]func(value string) error
+}
+
+// ValidationResult is the outcome of validating one request
+type ValidationResult struct {
+	// Route is the matched route pattern, or the request path when the
+	// framework has no pattern
+	Route string
+	// Passed reports whether the request had no validation or decoding errors.
+	// ReportOnly requests with errors did not pass, although they were let through.
+	Passed bool
+	// FailedFields lists the paths of the invalid fields once each, in the
+	// order reported, with array indexes removed so they can label metrics
+	// without one series per element: "items[3].sku" is listed as
+	// "items[].sku". It is empty when the body could not be decoded.
+	FailedFields []string
+}
+
+// DefaultMaxBodySize is the body size limit used by DefaultConfig.
+const DefaultMaxBodySize = 1 << 20
+
+// DefaultMaxStreamBodySize is the body size limit used by the ValidateStream middleware.
+const DefaultMaxStreamBodySize = 64 << 20
+
+// DefaultConfig returns the configuration used by ValidateRequest.
+func DefaultConfig() Config {
+	return Config{
+		MaxBodySize:       DefaultMaxBodySize,
+		ValidationStatus:  http.StatusUnprocessableEntity,
+		ValidationMessage: "invalid request",
+		DecodeStatus:      http.StatusBadRequest,
+	}
+}
+
+// WithDefaults returns cfg with unset status codes and messages filled from
+// DefaultConfig. Framework adapters call it once when building their middleware.
+func (cfg Config) WithDefaults() Config {
+	defaults := DefaultConfig()
+	if cfg.ValidationStatus == 0 {
+		cfg.ValidationStatus = defaults.ValidationStatus
+	}
+	if cfg.ValidationMessage == "" {
+		cfg.ValidationMessage = defaults.ValidationMessage
+	}
+	if cfg.DecodeStatus == 0 {
+		cfg.DecodeStatus = defaults.DecodeStatus
+	}
+	return cfg
+}
+
+// FailureResponse builds the response for an error returned by ValidateBody,
+// i.e. one that stopped validation altogether
+func FailureResponse(cfg Config, err error) ResponseBody {
+	response := failureResponse(cfg, err)
+	response.problem = cfg.ProblemDetails && !errors.Is(err, ErrReadBody)
+	return response
+}
+
+// failureResponse is FailureResponse in the default format
+func failureResponse(cfg Config, err error) ResponseBody {
+	switch {
+	case isDecodeError(err):
+		return errorResponse(cfg.DecodeStatus, decodeMessage(cfg, err))
+	case errors.Is(err, ErrTooDeeplyNested):
+		return errorResponse(http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrDuplicateKey), errors.Is(err, ErrEmptyBody), errors.Is(err, ErrNotJSONObject):
+		return errorResponse(http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return errorResponse(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, ErrReadBody):
+		return errorResponse(http.StatusBadRequest, ReadErrorMessage(err))
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return errorResponse(http.StatusServiceUnavailable, "validation cancelled")
+	default:
+		// Errors from the walk other than the ones above
+		return errorResponse(cfg.ValidationStatus, "Validation error")
+	}
+}
+
+// decodeMessage returns the message sent for a body decoding error
+func decodeMessage(cfg Config, err error) string {
+	if cfg.DecodeMessage != "" {
+		return cfg.DecodeMessage
+	}
+	return err.Error()
+}
+
+// errorResponse builds a ResponseBody carrying status and message
+func errorResponse(status int, message string) ResponseBody {
+	return ResponseBody{
+		StatusCode: status,
+		Message:    message,
+	}
+}
+
+// ErrMalformedJSON is returned by ValidateJSON when the body cannot be decoded.
+// The error returned wraps it with the position of the mistake, so test for
+// it with errors.Is.
+var ErrMalformedJSON = errors.New("malformed JSON body")
+
+// ErrMalformedForm is returned when a form-urlencoded or multipart body cannot be parsed.
+var ErrMalformedForm = errors.New("malformed form body")
+
+// multipartMemory is the number of bytes of multipart file parts kept in
+// memory while parsing; the whole body is already bounded by MaxBodySize
+const multipartMemory = 32 << 20
+
+// ValidateJSON runs the validation rules over a JSON document and returns the
+// collected validation errors. It is the framework independent core used by
+// the gin and net/http middlewares; an error means the body could not be
+// validated at all, e.g. ErrMalformedJSON.
+func ValidateJSON(body []byte) ([]string, error) {
+	_, validationErrors, err := validateJSON(context.Background(), body)
+	return errorStrings(validationErrors), err
+}
+
+// validateJSON decodes and validates body, also returning the decoded data
+func validateJSON(ctx context.Context, body []byte) (interface{}, []FieldError, error) {
+	jsonData, err := decodeBody(Config{}, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	validationErrors, err := validateData(ctx, Config{}, jsonData)
+	return jsonData, validationErrors, err
+}
+
+// ErrNotJSONObject is returned when RequireJSONObject is set and a JSON body
+// does not hold an object.
+var ErrNotJSONObject = errors.New("expected JSON object")
+
+// decodeBody decodes a JSON request body, which may be empty, rejecting
+// duplicate keys first when cfg.RejectDuplicateKeys is set
+func decodeBody(cfg Config, body []byte) (interface{}, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	if cfg.RejectDuplicateKeys {
+		if err := checkDuplicateKeys(body); err != nil {
+			return nil, err
+		}
+	}
+	jsonData, err := decodeJSON(body)
+	if err != nil {
+		return nil, malformedJSON(body, err)
+	}
+	if _, ok := jsonData.(map[string]interface{}); cfg.RequireJSONObject && !ok {
+		return nil, ErrNotJSONObject
+	}
+	return jsonData, nil
+}
+
+// decodeJSON decodes a single JSON value from body. It decodes into interface{}
+// so top-level arrays and scalars are validated too, and keeps numbers as
+// json.Number so large integer IDs do not lose precision.
+func decodeJSON(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var jsonData interface{}
+	if err := decoder.Decode(&jsonData); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		// Unmarshal reports the trailing data as a *json.SyntaxError with its offset
+		if err := json.Unmarshal(body, new(interface{})); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return jsonData, nil
+}
+
+// malformedJSON wraps ErrMalformedJSON with the position of the decoding
+// error err in body, e.g. "malformed JSON body at offset 8 (line 1, column 8):
+// invalid character '}' looking for beginning of object key string", so
+// clients can find the mistake. The offset is the number of bytes read up to
+// and including the offending one, as in json.SyntaxError.
+func malformedJSON(body []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		offset = int64(len(body))
+		err = io.ErrUnexpectedEOF
+	default:
+		return ErrMalformedJSON
+	}
+	offset = min(max(offset, 0), int64(len(body)))
+
+	read := body[:offset]
+	line := bytes.Count(read, []byte("\n")) + 1
+	column := len(read) - bytes.LastIndexByte(read, '\n') - 1
+	return fmt.Errorf("%w at offset %d (line %d, column %d): %s", ErrMalformedJSON, offset, line, column, err.Error())
+}
+
+// Validate runs the validation rules over already decoded JSON data and
+// returns the collected error messages, or nil if the data is valid. It needs
+// no HTTP request, so it can be used in unit tests and background jobs.
+func Validate(jsonData map[string]interface{}) []string {
+	validationErrors, err := validateData(context.Background(), Config{}, jsonData)
+	messages := errorStrings(validationErrors)
+	if err != nil {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+// validateData walks jsonData and checks required fields
+func validateData(ctx context.Context, cfg Config, jsonData interface{}) ([]FieldError, error) {
+	return validateDocument(ctx, cfg, jsonData, 0)
+}
+
+// validateDocument is validateData for a document nested depth levels deep,
+// as are the elements of a streamed array
+func validateDocument(ctx context.Context, cfg Config, jsonData interface{}, depth int) ([]FieldError, error) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	var validationErrors []FieldError
+
+	// Validate recursively
+	if err := validateNested(ctx, cfg, "", "", jsonData, depth, &validationErrors); err != nil {
+		return validationErrors, err
+	}
+	validateRequiredFields(cfg, jsonData, &validationErrors)
+	validateConditionalRules(jsonData, &validationErrors)
+	validateExactlyOne(jsonData, &validationErrors)
+	return validationErrors, nil
+}
+
+// ValidateBody validates a request body according to its Content-Type and is
+// the framework independent entry point of the middlewares: JSON bodies are
+// decoded and walked, form-urlencoded and multipart bodies have their values
+// validated, bodies of a type added with RegisterContentTypeHandler are
+// decoded by its parser and walked, and other bodies are not inspected. It
+// returns the decoded JSON data, the field failures, and an error when the
+// body could not be validated at all, which FailureResponse turns into a
+// response. The walk stops early with ctx.Err() once ctx, usually the request
+// context, is done.
+func ValidateBody(ctx context.Context, cfg Config, contentType string, body []byte) (interface{}, []FieldError, error) {
+	if err := checkContentType(cfg, contentType, body); err != nil {
+		return nil, nil, err
+	}
+	if !parsesAsForm(contentType) {
+		jsonData, err := decodeDocument(cfg, contentType, body)
+		if err != nil {
+			return nil, nil, err
+		}
+		sanitizeData(jsonData)
+		validated := trimData(cfg, jsonData)
+		validationErrors, err := validateData(ctx, cfg, validated)
+		return storedData(cfg, jsonData, validated), validationErrors, err
+	}
+	values, err := parseFormBody(contentType, body)
+	if err != nil {
+		return nil, nil, ErrMalformedForm
+	}
+	return nil, ValidateValues(cfg, values), nil
+}
+
+// ErrReadBody is returned by ValidateBodyStream when the body cannot be read,
+// e.g. past the limit set by StreamBody; ReadErrorMessage describes it.
+var ErrReadBody = errors.New("failed to read request body")
+
+// ValidateBodyStream is ValidateBody for bulk bodies holding a large
+// top-level JSON array: the elements are decoded from body and validated as
+// they arrive, so neither the body nor the decoded document is held in memory
+// at once. Each element is validated as a document of its own, so required
+// fields and the other document rules apply per element, and failures are
+// reported under the element index, e.g. "[3].mobile". With
+// cfg.StreamFailFast validation stops at the first invalid element, without
+// reading the rest of the body. Bodies that are not JSON arrays are read
+// whole, up to cfg.MaxBodySize when positive, and validated by ValidateBody,
+// and no decoded data is returned.
+func ValidateBodyStream(ctx context.Context, cfg Config, contentType string, body io.Reader) ([]FieldError, error) {
+	reader := bufio.NewReader(body)
+	leading := leadingBytes(reader)
+	if !IsJSONContentType(contentType) || contentTypeHandler(contentType) != nil || !bytes.HasSuffix(leading, []byte("[")) {
+		data, err := readAtMost(reader, cfg.MaxBodySize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
+		}
+		_, validationErrors, err := ValidateBody(ctx, cfg, contentType, data)
+		return validationErrors, err
+	}
+	if err := checkContentType(cfg, contentType, leading); err != nil {
+		return nil, err
+	}
+	if cfg.RequireJSONObject {
+		return nil, ErrNotJSONObject
+	}
+
+	counted := &countingReader{reader: reader}
+	decoder := json.NewDecoder(counted)
+	decoder.UseNumber()
+	if _, err := decoder.Token(); err != nil {
+		return nil, streamError(counted, err)
+	}
+	var validationErrors []FieldError
+	for i := 0; decoder.More(); i++ {
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return validationErrors, streamError(counted, err)
+		}
+		if cfg.RejectDuplicateKeys {
+			if err := checkDuplicateKeys(element); err != nil {
+				return validationErrors, err
+			}
+		}
+		item, err := decodeJSON(element)
+		if err != nil {
+			return validationErrors, malformedJSON(element, err)
+		}
+		sanitizeData(item)
+		itemErrors, err := validateDocument(ctx, cfg, trimData(cfg, item), 1)
+		for _, itemError := range itemErrors {
+			itemError.Field = elementPath(i, itemError.Field)
+			validationErrors = append(validationErrors, itemError)
+		}
+		if err != nil {
+			return validationErrors, err
+		}
+		if cfg.StreamFailFast && len(validationErrors) > 0 {
+			return validationErrors, nil
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return validationErrors, streamError(counted, err)
+	}
+	switch _, err := decoder.Token(); err {
+	case io.EOF:
+		return validationErrors, nil
+	case nil:
+		return validationErrors, fmt.Errorf("%w at offset %d: invalid character after top-level value", ErrMalformedJSON, decoder.InputOffset())
+	default:
+		return validationErrors, streamError(counted, err)
+	}
+}
+
+// leadingBytes peeks at reader up to its first non-whitespace byte, which
+// ends the returned bytes unless the body is blank
+func leadingBytes(reader *bufio.Reader) []byte {
+	for n := 1; ; n++ {
+		peeked, err := reader.Peek(n)
+		if err != nil || strings.IndexByte(" \t\r\n", peeked[n-1]) < 0 {
+			return peeked
+		}
+	}
+}
+
+// readAtMost reads reader to the end, failing with an *http.MaxBytesError
+// past maxBytes when the limit is positive
+func readAtMost(reader io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		return io.ReadAll(reader)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err == nil && int64(len(data)) > maxBytes {
+		err = &http.MaxBytesError{Limit: maxBytes}
+	}
+	return data, err
+}
+
+// CheckEmptyStream is CheckEmptyBody for a body read by ValidateBodyStream:
+// only its leading whitespace is read, and the returned reader yields the
+// whole body.
+func CheckEmptyStream(cfg Config, method string, body io.Reader) (io.Reader, error) {
+	if !cfg.RejectEmptyBody {
+		return body, nil
+	}
+	reader := bufio.NewReader(body)
+	var blank []byte
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return bytes.NewReader(blank), CheckEmptyBody(cfg, method, blank)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
+		}
+		if strings.IndexByte(" \t\r\n", b) < 0 {
+			if err := reader.UnreadByte(); err != nil {
+				return nil, err
+			}
+			return io.MultiReader(bytes.NewReader(blank), reader), nil
+		}
+		blank = append(blank, b)
+	}
+}
+
+// countingReader counts the bytes read from reader
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+// streamError converts an error of the decoder reading the streamed body
+// counted: syntax errors and truncated bodies, whose offset is the body
+// length, are reported as ErrMalformedJSON, and read failures are wrapped in
+// ErrReadBody. There is no line and column since the body read so far is not kept.
+func streamError(counted *countingReader, err error) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("%w at offset %d: %s", ErrMalformedJSON, syntaxErr.Offset, err.Error())
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return fmt.Errorf("%w at offset %d: %s", ErrMalformedJSON, counted.read, io.ErrUnexpectedEOF.Error())
+	default:
+		return fmt.Errorf("%w: %w", ErrReadBody, err)
+	}
+}
+
+// elementPath prefixes a path within the element at index of a top-level array
+func elementPath(index int, path string) string {
+	prefix := fmt.Sprintf("[%d]", index)
+	if path == "" || strings.HasPrefix(path, "[") {
+		return prefix + path
+	}
+	return prefix + "." + path
+}
+
+// trimData returns the data validated for a request: when cfg.TrimSpace is
+// set, a copy of jsonData with surrounding whitespace removed from every
+// string value, and jsonData itself otherwise
+func trimData(cfg Config, jsonData interface{}) interface{} {
+	if !cfg.TrimSpace {
+		return jsonData
+	}
+	return trimStrings(jsonData)
+}
+
+// storedData returns the data handed to downstream handlers: the trimmed copy
+// when cfg.StoreTrimmed is set, and the data as sent otherwise
+func storedData(cfg Config, jsonData, validated interface{}) interface{} {
+	if cfg.StoreTrimmed {
+		return validated
+	}
+	return jsonData
+}
+
+// trimStrings returns a copy of input with every string value trimmed
+func trimStrings(input interface{}) interface{} {
+	switch v := input.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		trimmed := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			trimmed[key] = trimStrings(value)
+		}
+		return trimmed
+	case []interface{}:
+		trimmed := make([]interface{}, len(v))
+		for i, item := range v {
+			trimmed[i] = trimStrings(item)
+		}
+		return trimmed
+	default:
+		return input
+	}
+}
+
+// parseFormBody parses a form-urlencoded or multipart body, returning its
+// non-file values. File parts are skipped, and those spilled to temporary
+// files are removed before returning.
+func parseFormBody(contentType string, body []byte) (map[string][]string, error) {
+	r, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", contentType)
+	if mediaType(contentType) == "multipart/form-data" {
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			return nil, err
+		}
+		defer r.MultipartForm.RemoveAll()
+		return r.MultipartForm.Value, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return r.PostForm, nil
+}
+
+// isDecodeError reports whether err means the body could not be decoded
+func isDecodeError(err error) bool {
+	return errors.Is(err, ErrMalformedJSON) || errors.Is(err, ErrMalformedForm) || errors.Is(err, ErrMalformedBody)
+}
+
+// ReadBody reads the body of r, capped at maxBytes when the limit is positive,
+// and resets it so downstream handlers can read it again. A gzip or deflate
+// Content-Encoding is decoded, with the limit applying to the decompressed
+// size too, and downstream handlers get the decompressed body.
+// ReadErrorMessage describes a returned error.
+func ReadBody(w http.ResponseWriter, r *http.Request, maxBytes int64) ([]byte, error) {
+	limitRequestBody(w, r, maxBytes)
+	body, err := readRequestBody(r)
+	if err != nil {
+		return body, err
+	}
+	return decodeContentEncoding(r, body, maxBytes)
+}
+
+var (
+	// errMalformedEncoding is returned when a compressed body cannot be decompressed
+	errMalformedEncoding = errors.New("malformed compressed body")
+	// errDecompressedTooLarge is returned when a body decompresses to more than the limit
+	errDecompressedTooLarge = errors.New("decompressed body too large")
+)
+
+// decodeContentEncoding decompresses a gzip or deflate encoded body with
+// DecodeBody. The decompressed body replaces the body of r and the
+// Content-Encoding header is removed. Other bodies are returned as is.
+func decodeContentEncoding(r *http.Request, body []byte, maxBytes int64) ([]byte, error) {
+	decoded, err := decompressBody(r.Header.Get("Content-Encoding"), body, maxBytes)
+	if err != nil || decoded == nil {
+		return body, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(decoded))
+	r.ContentLength = int64(len(decoded))
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	return decoded, nil
+}
+
+// DecodeBody applies the checks of ReadBody to a body already read by the
+// framework: it fails past maxBytes when the limit is positive, and a gzip or
+// deflate contentEncoding is decoded with the limit applying to the
+// decompressed size too. ReadErrorMessage describes a returned error.
+func DecodeBody(contentEncoding string, body []byte, maxBytes int64) ([]byte, error) {
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return nil, &http.MaxBytesError{Limit: maxBytes}
+	}
+	decoded, err := decompressBody(contentEncoding, body, maxBytes)
+	if err != nil || decoded == nil {
+		return body, err
+	}
+	return decoded, nil
+}
+
+// decompressBody decompresses a gzip or deflate encoded body, reading at
+// most maxBytes when the limit is positive so a small compressed body cannot
+// expand without bound. It returns nil for other encodings.
+func decompressBody(contentEncoding string, body []byte, maxBytes int64) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		reader, err = zlib.NewReader(bytes.NewReader(body))
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errMalformedEncoding
+	}
+	defer reader.Close()
+
+	var source io.Reader = reader
+	if maxBytes > 0 {
+		source = io.LimitReader(reader, maxBytes+1)
+	}
+	decoded, err := io.ReadAll(source)
+	if err != nil {
+		return nil, errMalformedEncoding
+	}
+	if maxBytes > 0 && int64(len(decoded)) > maxBytes {
+		return nil, errDecompressedTooLarge
+	}
+	return decoded, nil
+}
+
+// StreamBody prepares the body of r for ValidateBodyStream without reading
+// it: it is capped at maxBytes when the limit is positive, and a gzip or
+// deflate Content-Encoding is decoded as the body is read, with the limit
+// applying to the decompressed size too. The Content-Encoding and
+// Content-Length headers are then removed, since the returned reader yields
+// the decompressed body. ReadErrorMessage describes a returned error.
+func StreamBody(w http.ResponseWriter, r *http.Request, maxBytes int64) (io.Reader, error) {
+	limitRequestBody(w, r, maxBytes)
+	if r.Body == nil {
+		return http.NoBody, nil
+	}
+	var reader io.Reader
+	var err error
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(r.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(r.Body)
+	default:
+		return r.Body, nil
+	}
+	if err != nil {
+		return nil, errMalformedEncoding
+	}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	return &decodedReader{reader: reader, limit: maxBytes}, nil
+}
+
+// decodedReader reads a decompressed body, reporting corrupt data as
+// errMalformedEncoding and more than limit bytes, when positive, as
+// errDecompressedTooLarge
+type decodedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (d *decodedReader) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	d.read += int64(n)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case d.limit > 0 && d.read > d.limit:
+		return n, errDecompressedTooLarge
+	case err == nil, err == io.EOF, errors.As(err, &maxBytesErr):
+		return n, err
+	default:
+		return n, errMalformedEncoding
+	}
+}
+
+// limitRequestBody caps the body of r at maxBytes when the limit is positive
+func limitRequestBody(w http.ResponseWriter, r *http.Request, maxBytes int64) {
+	if maxBytes > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+}
+
+// readRequestBody reads the whole request body once and resets it so
+// downstream handlers can read it again
+func readRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	requestBody, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(requestBody))
+	return requestBody, err
+}
+
+// ReadErrorMessage describes a failure returned by ReadBody
+func ReadErrorMessage(err error) string {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || errors.Is(err, errDecompressedTooLarge) {
+		return "request body too large"
+	}
+	if errors.Is(err, errMalformedEncoding) {
+		return err.Error()
+	}
+	return "failed to read request body"
+}
+
+// jsonBody returns body if the Content-Type declares JSON and nil otherwise,
+// so non-JSON bodies are not decoded
+func jsonBody(contentType string, body []byte) []byte {
+	if !IsJSONContentType(contentType) {
+		return nil
+	}
+	return body
+}
+
+// IsJSONContentType reports whether contentType declares a JSON body. An
+// empty Content-Type is treated as JSON.
+func IsJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType := mediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isFormContentType reports whether contentType declares a form-urlencoded or multipart body
+func isFormContentType(contentType string) bool {
+	switch mediaType(contentType) {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		return true
+	default:
+		return false
+	}
+}
+
+// mediaType returns the media type of a Content-Type header without
+// parameters, or "" when it cannot be parsed
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// skipPaths holds the exact paths and path prefixes exempt from validation
+var (
+	skipPaths        map[string]bool
+	skipPathPrefixes []string
+)
+
+// SetSkipPaths exempts routes from validation: the middlewares call the next
+// handler straight away. Entries are matched against the gin or echo route
+// pattern, e.g. "/users/:id", or, for net/http, fiber and unmatched routes,
+// the request path. An entry ending in '*' matches every path starting with
+// the text before it, e.g. "/uploads/*".
+func SetSkipPaths(paths []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	skipPaths = make(map[string]bool, len(paths))
+	skipPathPrefixes = nil
+	for _, path := range paths {
+		if strings.HasSuffix(path, "*") {
+			skipPathPrefixes = append(skipPathPrefixes, strings.TrimSuffix(path, "*"))
+			continue
+		}
+		skipPaths[path] = true
+	}
+}
+
+// IsSkippedPath reports whether path was exempted with SetSkipPaths
+func IsSkippedPath(path string) bool {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if skipPaths[path] {
+		return true
+	}
+	for _, prefix := range skipPathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTrustedRequest reports whether a request may skip validation through
+// cfg.TrustedHeader. header looks up request headers and remoteAddr is the
+// peer address of the connection, with or without a port.
+func IsTrustedRequest(cfg Config, header func(key string) string, remoteAddr string) bool {
+	if cfg.TrustedHeader == "" || len(cfg.TrustedNetworks) == 0 {
+		return false
+	}
+	if trusted, _ := strconv.ParseBool(header(cfg.TrustedHeader)); !trusted {
+		return false
+	}
+	addr, err := netip.ParseAddr(remoteAddr)
+	if err != nil {
+		addrPort, err := netip.ParseAddrPort(remoteAddr)
+		if err != nil {
+			return false
+		}
+		addr = addrPort.Addr()
+	}
+	addr = addr.Unmap()
+	for _, network := range cfg.TrustedNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportValidationErrors logs the errors of a ReportOnly request and, when
+// WarningsHeader is configured, adds it to the response through setHeader
+func ReportValidationErrors(cfg Config, validationErrors []FieldError, setHeader func(key, value string)) {
+	messages := errorStrings(validationErrors)
+	currentLogger().Error("@Validation warning:", messages)
+	if cfg.WarningsHeader != "" {
+		setHeader(cfg.WarningsHeader, strings.Join(messages, "; "))
+	}
+}
+
+// NotifyValidationResult passes the outcome of validating the request for route
+// to cfg.OnValidationResult, if set. err is the decoding or reading error, if any.
+func NotifyValidationResult(cfg Config, route string, validationErrors []FieldError, err error) {
+	if cfg.OnValidationResult == nil {
+		return
+	}
+	result := ValidationResult{Route: route, Passed: err == nil && len(validationErrors) == 0}
+	seen := make(map[string]bool, len(validationErrors))
+	for _, validationError := range validationErrors {
+		field := arrayIndexRegex.ReplaceAllString(validationError.Field, "[]")
+		if !seen[field] {
+			seen[field] = true
+			result.FailedFields = append(result.FailedFields, field)
+		}
+	}
+	cfg.OnValidationResult(result)
+}
+
+// ValidationErrorResponse logs the errors and builds the validation failure
+// response body, with messages rendered in locale as returned by Locale
+func ValidationErrorResponse(cfg Config, validationErrors []FieldError, locale string) ResponseBody {
+	currentLogger().Error("@Validation error:", errorStrings(validationErrors))
+	return validationErrorBody(cfg, validationErrors, locale)
+}
+
+// InvalidResponseBody logs the failures of a response body validated by a
+// response middleware, under a label of their own so they are not mistaken
+// for rejected requests, and builds the 500 response listing them
+func InvalidResponseBody(validationErrors []FieldError) ResponseBody {
+	currentLogger().Error("@Response validation error:", errorStrings(validationErrors))
+	return validationErrorBody(Config{
+		ReturnErrors:      true,
+		ValidationStatus:  http.StatusInternalServerError,
+		ValidationMessage: "invalid response",
+	}, validationErrors, "")
+}
+
+// validationErrorBody builds the validation failure response body
+func validationErrorBody(cfg Config, validationErrors []FieldError, locale string) ResponseBody {
+	response := errorResponse(cfg.ValidationStatus, cfg.ValidationMessage)
+	response.problem = cfg.ProblemDetails
+	if cfg.ReturnErrors {
+		localized := LocalizeErrors(validationErrors, locale)
+		response.Errors = errorStrings(localized)
+		response.Details = localized
+	}
+	return response
+}
+
+// ValidateValues validates every value of a multi-valued key set such as a
+// query string or path parameters, reporting errors against the key. Values
+// are trimmed first when cfg.TrimSpace is set.
+func ValidateValues(cfg Config, values map[string][]string) []FieldError {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if cfg.TrimSpace {
+		trimmed := make(map[string][]string, len(values))
+		for key, list := range values {
+			for _, value := range list {
+				trimmed[key] = append(trimmed[key], strings.TrimSpace(value))
+			}
+		}
+		values = trimmed
+	}
+	var validationErrors []FieldError
+	validateValues(cfg, values, &validationErrors)
+	return validationErrors
+}
+
+// ValidateHeaderValues validates request headers: rules maps header names to
+// the field key whose rules apply, e.g. "X-Client-Mobile" to "mobile", and get
+// returns a header of the request. Absent headers are not checked; errors are
+// reported against the header name.
+func ValidateHeaderValues(cfg Config, rules map[string]string, get func(name string) string) []FieldError {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	var validationErrors []FieldError
+	for _, name := range sortedKeys(rules) {
+		value := get(name)
+		if cfg.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+		if value != "" {
+			validateField(cfg, rules[name], name, value, &validationErrors)
+		}
+	}
+	return validationErrors
+}
+
+// validateValues appends the failures of every value in values to validationErrors
+func validateValues(cfg Config, values map[string][]string, validationErrors *[]FieldError) {
+	for _, key := range sortedKeys(values) {
+		for _, value := range values[key] {
+			if err := validateStringSize(value); err != nil {
+				addValidationError(validationErrors, key, err)
+				continue
+			}
+			validateScalar(cfg, key, key, value, validationErrors)
+			validateField(cfg, key, key, value, validationErrors)
+		}
+	}
+}
+
+// ErrEmptyBody is returned by CheckEmptyBody when RejectEmptyBody is set and
+// a request that carries a body has none.
+var ErrEmptyBody = errors.New("empty request body")
+
+// CheckEmptyBody enforces RejectEmptyBody for a request with method and body
+func CheckEmptyBody(cfg Config, method string, body []byte) error {
+	if !cfg.RejectEmptyBody || len(bytes.TrimSpace(body)) > 0 {
+		return nil
+	}
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return ErrEmptyBody
+	default:
+		return nil
+	}
+}
+
+// ErrDuplicateKey is returned when RejectDuplicateKeys is set and a JSON
+// object repeats a key; the error names the key.
+var ErrDuplicateKey = errors.New("duplicate JSON key")
+
+// jsonFrame is an object or array opened while scanning for duplicate keys
+type jsonFrame struct {
+	// keys holds the keys seen so far in an object, nil for an array
+	keys map[string]bool
+	// expectKey reports whether the next token of an object is a key
+	expectKey bool
+}
+
+// checkDuplicateKeys scans the tokens of body for an object repeating a key.
+// Syntax errors are left to the decoder, which reports them.
+func checkDuplicateKeys(body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var stack []*jsonFrame
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		if n := len(stack); n > 0 && stack[n-1].keys != nil && stack[n-1].expectKey {
+			if key, ok := token.(string); ok {
+				if stack[n-1].keys[key] {
+					return fmt.Errorf("%w '%s'", ErrDuplicateKey, key)
+				}
+				stack[n-1].keys[key] = true
+				stack[n-1].expectKey = false
+				continue
+			}
+		}
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &jsonFrame{keys: map[string]bool{}, expectKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, &jsonFrame{})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+		// A value has ended, so an enclosing object expects its next key
+		if n := len(stack); n > 0 && stack[n-1].keys != nil {
+			stack[n-1].expectKey = true
+		}
+	}
+}
+
+// ErrUnsupportedMediaType is returned when RequireJSONContentType is set and
+// the request body is not declared as JSON, or when the body has a type
+// without a parser once RegisterContentTypeHandler has been used.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ErrMalformedBody is returned when a parser added with
+// RegisterContentTypeHandler fails; the error returned wraps it with the
+// parser's error.
+var ErrMalformedBody = errors.New("malformed request body")
+
+// contentTypeHandlers holds the parsers added through
+// RegisterContentTypeHandler, keyed by media type
+var contentTypeHandlers = map[string]func(body []byte) (map[string]interface{}, error){}
+
+// RegisterContentTypeHandler makes bodies declared as mime, e.g.
+// "application/xml" or "text/csv", acceptable and validated like JSON bodies
+// once parser has decoded them. Parameters such as "; charset=utf-8" are
+// ignored, and a handler for JSON, form-urlencoded or multipart replaces the
+// built-in parser of that type. Once a handler is registered, bodies of any
+// other type than these and the registered ones are rejected with 415
+// Unsupported Media Type instead of passing uninspected.
+func RegisterContentTypeHandler(mime string, parser func(body []byte) (map[string]interface{}, error)) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	contentTypeHandlers[mediaType(mime)] = parser
+}
+
+// contentTypeHandler returns the parser registered for contentType, or nil
+func contentTypeHandler(contentType string) func(body []byte) (map[string]interface{}, error) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if mediaType := mediaType(contentType); mediaType != "" {
+		return contentTypeHandlers[mediaType]
+	}
+	return nil
+}
+
+// parsesAsForm reports whether a body of contentType is parsed as a form by
+// the built-in parser
+func parsesAsForm(contentType string) bool {
+	return isFormContentType(contentType) && contentTypeHandler(contentType) == nil
+}
+
+// decodeDocument decodes a body that is not parsed as a form, with the
+// parser registered for contentType or else as JSON
+func decodeDocument(cfg Config, contentType string, body []byte) (interface{}, error) {
+	parser := contentTypeHandler(contentType)
+	if parser == nil {
+		return decodeBody(cfg, jsonBody(contentType, body))
+	}
+	if len(body) == 0 {
+		return nil, nil
+	}
+	data, err := parser(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedBody, err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// checkContentType enforces RequireJSONContentType for requests carrying a
+// body, and rejects bodies of types nothing parses once a content type
+// handler is registered
+func checkContentType(cfg Config, contentType string, body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+	if cfg.RequireJSONContentType && (contentType == "" || !IsJSONContentType(contentType)) {
+		return ErrUnsupportedMediaType
+	}
+	rulesMu.RLock()
+	restricted := len(contentTypeHandlers) > 0
+	rulesMu.RUnlock()
+	if restricted && !IsJSONContentType(contentType) && !isFormContentType(contentType) && contentTypeHandler(contentType) == nil {
+		return ErrUnsupportedMediaType
+	}
+	return nil
+}
+
+// ErrTooDeeplyNested is returned when a document nests objects and arrays
+// deeper than the limit set with SetMaxDepth.
+var ErrTooDeeplyNested = errors.New("request too deeply nested")
+
+// DefaultMaxDepth is the default limit on nested objects and arrays.
+const DefaultMaxDepth = 32
+
+var maxDepth = DefaultMaxDepth
+
+// SetMaxDepth limits how many objects and arrays may be nested inside each
+// other; deeper documents are rejected with ErrTooDeeplyNested. Zero or a
+// negative value disables the limit.
+func SetMaxDepth(depth int) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	maxDepth = depth
+}
+
+// maxStringLength caps the size of every string value, zero when unlimited
+var maxStringLength int
+
+// SetMaxStringLength rejects any string value longer than n bytes, e.g.
+// 10 << 10, whatever its field, before other rules run on it. It complements
+// the per-field SetFieldLength, which counts characters. Zero or a negative
+// value disables the limit, which is the default.
+func SetMaxStringLength(n int) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	maxStringLength = n
+}
+
+// validateStringSize checks a decoded value against the SetMaxStringLength limit
+func validateStringSize(value interface{}) error {
+	if s, ok := value.(string); ok && maxStringLength > 0 && len(s) > maxStringLength {
+		return messageError("too_large", len(s), maxStringLength)
+	}
+	return nil
+}
+
+// validateNested walks input found under key, reporting errors against its
+// path in the document, e.g. "user.contacts[0].mobile". depth is the number
+// of objects and arrays enclosing input.
+//
+// Field failures are only ever appended to validationErrors and never stop the
+// walk. A returned error means the document cannot be validated at all, such
+// as ErrTooDeeplyNested or the error of a cancelled ctx, and aborts the walk.
+func validateNested(ctx context.Context, cfg Config, key, path string, input interface{}, depth int, validationErrors *[]FieldError) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch v := input.(type) {
+	case map[string]interface{}:
+		if maxDepth > 0 && depth >= maxDepth {
+			return ErrTooDeeplyNested
+		}
+		return validateNestedMap(ctx, cfg, path, v, depth, validationErrors)
+	case []interface{}:
+		if maxDepth > 0 && depth >= maxDepth {
+			return ErrTooDeeplyNested
+		}
+		return validateNestedArray(ctx, cfg, key, path, v, depth, validationErrors)
+	default:
+		validateScalar(cfg, key, path, input, validationErrors)
+		return nil
+	}
+}
+
+// validateScalar applies the checks every scalar value gets regardless of
+// its field: numeric ranges, the blocklist and the general format
+func validateScalar(cfg Config, key, path string, value interface{}, validationErrors *[]FieldError) {
+	if err := validateNumericRange(key, value); err != nil {
+		addValidationError(validationErrors, path, err)
+	}
+	if err := validateBlocklist(value); err != nil {
+		addValidationError(validationErrors, path, err)
+	}
+	if !cfg.TargetedValidation && !skipsGeneralFormat(cfg, key) && !isValidGeneralFormat(value) {
+		addValidationError(validationErrors, path, invalidFormatError(key, value))
+	}
+}
+
+// blocklistPatterns are the patterns set with SetBlocklistPatterns
+var blocklistPatterns []*regexp.Regexp
+
+// SetBlocklistPatterns rejects any string value matching one of patterns,
+// e.g. `(?i)<script` or `(?i)drop\s+table`, with "potentially malicious
+// content detected". The blocklist is empty by default. If a pattern does not
+// compile an error is returned and the current blocklist is kept.
+func SetBlocklistPatterns(patterns []string) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, re)
+	}
+	blocklistPatterns = compiled
+	return nil
+}
+
+// validateBlocklist checks a string value against the blocklist patterns
+func validateBlocklist(value interface{}) error {
+	str, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	for _, re := range blocklistPatterns {
+		if re.MatchString(str) {
+			return messageError("malicious_content")
+		}
+	}
+	return nil
+}
+
+// validateNestedMap walks every key of an object in sorted order, so that all
+// failures are reported and always in the same order
+func validateNestedMap(ctx context.Context, cfg Config, path string, input map[string]interface{}, depth int, validationErrors *[]FieldError) error {
+	for _, key := range sortedKeys(input) {
+		value := input[key]
+		fieldPath := joinPath(path, key)
+		if err := validateFieldName(key); err != nil {
+			addValidationError(validationErrors, joinPath(path, printableKey(key)), err)
+			continue
+		}
+		if !isFieldAllowed(path, key) {
+			addValidationError(validationErrors, fieldPath, messageError("unexpected_field", key))
+			continue
+		}
+		if value == nil {
+			// null is treated as an absent value: no format rules apply, and
+			// a required field holding null is reported by validateRequiredFields
+			continue
+		}
+		if err := validateStringSize(value); err != nil {
+			addValidationError(validationErrors, fieldPath, err)
+			continue
+		}
+		if err := validateFieldType(key, value, expectedTypes[key]); err != nil {
+			addValidationError(validationErrors, fieldPath, err)
+			continue
+		}
+		if err := validateNested(ctx, cfg, key, fieldPath, value, depth+1, validationErrors); err != nil {
+			return err
+		}
+		if isScalar(value) {
+			validateField(cfg, key, fieldPath, getStringValue(value), validationErrors)
+		}
+	}
+	return nil
+}
+
+// isScalar reports whether a decoded value is neither an object nor an array
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+var (
+	// checkFieldNames enables the field name check set with SetFieldNameRules
+	checkFieldNames bool
+	// fieldNameRegex is the pattern field names must match, nil for any
+	fieldNameRegex *regexp.Regexp
+	// blockedFieldNames are the field names always rejected
+	blockedFieldNames map[string]bool
+)
+
+// SetFieldNameRules enables checking the keys of JSON objects, reporting
+// "invalid field name" for keys that hold control characters such as
+// newlines, do not match pattern when it is not empty, e.g.
+// `^[A-Za-z0-9_.-]+$`, or are one of blocked, e.g. "__proto__" or
+// "constructor". The fields below a rejected key are not validated. Names
+// are checked exactly as sent. The check is off by default; an invalid
+// pattern returns an error and leaves the current rules in place.
+func SetFieldNameRules(pattern string, blocked []string) error {
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return err
+		}
+	}
+	names := make(map[string]bool, len(blocked))
+	for _, name := range blocked {
+		names[name] = true
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	checkFieldNames, fieldNameRegex, blockedFieldNames = true, re, names
+	return nil
+}
+
+// validateFieldName checks a JSON object key against the SetFieldNameRules rules
+func validateFieldName(key string) error {
+	if !checkFieldNames {
+		return nil
+	}
+	if strings.IndexFunc(key, unicode.IsControl) >= 0 || blockedFieldNames[key] ||
+		fieldNameRegex != nil && !fieldNameRegex.MatchString(key) {
+		return messageError("invalid_field_name")
+	}
+	return nil
+}
+
+// printableKey returns key quoted when it holds control characters, so a
+// rejected name cannot inject newlines into logs
+func printableKey(key string) string {
+	if strings.IndexFunc(key, unicode.IsControl) >= 0 {
+		return strconv.QuoteToASCII(key)
+	}
+	return key
+}
+
+// skipsGeneralFormat reports whether fields named key are exempt from the
+// general format check: free-text fields, and built-in fields checking their
+// characters themselves unless their validator is disabled. Base64 fields
+// and mobile fields in E.164 mode, whose leading '+' the default pattern
+// rejects, are exempt too.
+func skipsGeneralFormat(cfg Config, key string) bool {
+	if freeTextFields[normalizeKey(key)] {
+		return true
+	}
+	name := builtinKey(key)
+	if disabledValidators[name] {
+		return false
+	}
+	return ownFormatFields[name] || base64Keys[name] || mobileFields[name] && effectiveMobileMode(cfg.MobileMode) == MobileModeE164
+}
+
+// freeTextFields are the field names set with SetFreeTextFields
+var freeTextFields = map[string]bool{}
+
+// SetFreeTextFields replaces the fields holding human text, such as
+// "address" or "description", which are exempt from the general format
+// check so any punctuation is accepted. The blocklist and the length rules
+// still apply. Names are normalized as described on builtinKey.
+func SetFreeTextFields(keys []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	freeTextFields = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		freeTextFields[normalizeKey(key)] = true
+	}
+}
+
+// mobileFields are the built-in fields validated as mobile numbers
+var mobileFields = map[string]bool{"mobile": true, "contact": true, "phone": true}
+
+// ownFormatFields are built-in fields whose validator defines the allowed
+// characters itself, so they skip the general format check; the default
+// general pattern would reject the ':' of URLs, the '?', '&' and '%' of
+// their query strings, the symbols strong passwords need, the '+' of
+// plus-addressed emails, of exponents such as 1.5e+1, of time zone offsets
+// and of semantic version build metadata
+var ownFormatFields = map[string]bool{
+	"url":         true,
+	"website":     true,
+	"callbackurl": true,
+	"password":    true,
+	"email":       true,
+	"lat":         true,
+	"latitude":    true,
+	"lng":         true,
+	"lon":         true,
+	"longitude":   true,
+	"timestamp":   true,
+	"datetime":    true,
+	"createdat":   true,
+	"updatedat":   true,
+	"version":     true,
+	"appversion":  true,
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateNestedArray walks the elements of an array found under key. Scalar
+// elements of any mix of types get the scalar checks and field rules of key
+// and are reported by index, e.g. "[2]" for the third element of a top-level
+// array.
+func validateNestedArray(ctx context.Context, cfg Config, key, path string, input []interface{}, depth int, validationErrors *[]FieldError) error {
+	for i, item := range input {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if err := validateStringSize(item); err != nil {
+			addValidationError(validationErrors, itemPath, err)
+			continue
+		}
+		if err := validateNested(ctx, cfg, key, itemPath, item, depth+1, validationErrors); err != nil {
+			return err
+		}
+		// Scalar elements get the rules of the key holding the array
+		if item != nil && isScalar(item) {
+			validateField(cfg, key, itemPath, getStringValue(item), validationErrors)
+		}
+	}
+	return nil
+}
+
+var (
+	// allowedFields holds the field paths accepted in strict mode, nil when strict mode is off
+	allowedFields map[string]bool
+	// allowedParents holds the object paths whose keys are restricted in strict mode
+	allowedParents map[string]bool
+)
+
+var arrayIndexRegex = regexp.MustCompile(`\[\d+\]`)
+
+// SetAllowedFields enables strict mode: any field not in fields is reported as
+// "unexpected field". Entries are dotted paths without array indexes, e.g.
+// "user" and "user.name". Top-level keys are always checked; the keys of a
+// nested object are only checked when at least one entry names a field below
+// it. An empty list turns strict mode off, which is the default.
+func SetAllowedFields(fields []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if len(fields) == 0 {
+		allowedFields, allowedParents = nil, nil
+		return
+	}
+	allowedFields = make(map[string]bool, len(fields))
+	allowedParents = map[string]bool{"": true}
+	for _, field := range fields {
+		allowedFields[field] = true
+		for i := strings.LastIndex(field, "."); i > 0; i = strings.LastIndex(field[:i], ".") {
+			allowedParents[field[:i]] = true
+		}
+	}
+}
+
+// isFieldAllowed reports whether key may appear in the object at path
+func isFieldAllowed(path, key string) bool {
+	if allowedFields == nil {
+		return true
+	}
+	parent := arrayIndexRegex.ReplaceAllString(path, "")
+	if !allowedParents[parent] {
+		return true
+	}
+	return allowedFields[joinPath(parent, key)]
+}
+
+// requiredFields holds the dotted paths registered with RegisterRequiredFields
+var requiredFields []string
+
+// RegisterRequiredFields marks fields as required. Entries are dotted paths
+// such as "mobile" or "user.address.pincode". Calls are cumulative.
+//
+// A "[*]" suffix on a segment requires the rest of the path in every element
+// of the array there, e.g. "items[*].price", and failures name the element,
+// as in "items[3].price". An absent or empty array has no elements to check;
+// require "items" as well to reject it.
+//
+// A JSON null is treated exactly like an absent field: it skips the format
+// rules of optional fields, but a required field whose value, or any parent
+// object on its path, is null is reported as missing.
+func RegisterRequiredFields(fields []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	requiredFields = append(requiredFields, fields...)
+}
+
+// validateRequiredFields reports every registered required field that is absent or null in jsonData
+func validateRequiredFields(cfg Config, jsonData interface{}, validationErrors *[]FieldError) {
+	for _, fields := range [][]string{requiredFields, cfg.RequiredFields} {
+		for _, field := range fields {
+			for _, path := range missingPaths(jsonData, field) {
+				addValidationError(validationErrors, path, messageError("required"))
+			}
+		}
+	}
+}
+
+// ConditionalRule applies extra presence rules to a document when its
+// predicate holds, e.g. "if type is business then gstin is required".
+type ConditionalRule struct {
+	// When reports whether the rule applies to the decoded JSON object
+	When func(data map[string]interface{}) bool
+	// Required lists dotted paths that must be present and not null
+	Required []string
+	// Forbidden lists dotted paths that must be absent or null
+	Forbidden []string
+}
+
+var conditionalRules []ConditionalRule
+
+// RegisterConditionalRule adds a rule evaluated after the document has been
+// walked. Rules only apply to JSON object bodies.
+func RegisterConditionalRule(rule ConditionalRule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	conditionalRules = append(conditionalRules, rule)
+}
+
+// FieldEquals returns a ConditionalRule predicate that holds when the value
+// at the dotted path equals value, e.g. FieldEquals("type", "business").
+func FieldEquals(path string, value interface{}) func(data map[string]interface{}) bool {
+	return func(data map[string]interface{}) bool {
+		return lookupPath(data, path) == value
+	}
+}
+
+// validateConditionalRules evaluates every registered conditional rule against jsonData
+func validateConditionalRules(jsonData interface{}, validationErrors *[]FieldError) {
+	data, ok := jsonData.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, rule := range conditionalRules {
+		if rule.When == nil || !rule.When(data) {
+			continue
+		}
+		for _, field := range rule.Required {
+			for _, path := range missingPaths(data, field) {
+				addValidationError(validationErrors, path, messageError("required"))
+			}
+		}
+		for _, field := range rule.Forbidden {
+			if lookupPath(data, field) != nil {
+				addValidationError(validationErrors, field, messageError("forbidden"))
+			}
+		}
+	}
+}
+
+// exactlyOneGroups holds the field groups registered with RegisterExactlyOne
+var exactlyOneGroups [][]string
+
+// RegisterExactlyOne requires exactly one of fields, given as dotted paths,
+// to be present and not null, e.g. []string{"email", "mobile"} for logging in
+// by email or phone. Having none or several is reported as "exactly one of
+// [email, mobile] must be provided", without a field. Calls are cumulative and
+// groups only apply to JSON object bodies.
+func RegisterExactlyOne(fields []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	exactlyOneGroups = append(exactlyOneGroups, append([]string(nil), fields...))
+}
+
+// validateExactlyOne checks every group registered with RegisterExactlyOne against jsonData
+func validateExactlyOne(jsonData interface{}, validationErrors *[]FieldError) {
+	data, ok := jsonData.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, fields := range exactlyOneGroups {
+		present := 0
+		for _, field := range fields {
+			if lookupPath(data, field) != nil {
+				present++
+			}
+		}
+		if present != 1 {
+			addValidationError(validationErrors, "", messageError("exactly_one", strings.Join(fields, ", ")))
+		}
+	}
+}
+
+// lookupPath returns the value at a dotted path in data, or nil when any
+// segment is absent, null or not an object
+func lookupPath(data interface{}, path string) interface{} {
+	current := data
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[key]
+	}
+	return current
+}
+
+// missingPaths returns the concrete paths at which the required path is absent
+// or null in data, expanding "[*]" segments over the elements of arrays
+func missingPaths(data interface{}, path string) []string {
+	return expandMissing(data, "", strings.Split(path, "."))
+}
+
+// expandMissing checks the remaining segments below current, found at prefix
+func expandMissing(current interface{}, prefix string, segments []string) []string {
+	if len(segments) == 0 {
+		if current == nil {
+			return []string{prefix}
+		}
+		return nil
+	}
+	key, wildcard := strings.CutSuffix(segments[0], "[*]")
+	if key != "" {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return []string{joinPath(prefix, strings.Join(segments, "."))}
+		}
+		current, prefix = object[key], joinPath(prefix, key)
+	}
+	if !wildcard {
+		return expandMissing(current, prefix, segments[1:])
+	}
+	items, _ := current.([]interface{})
+	var missing []string
+	for i, item := range items {
+		missing = append(missing, expandMissing(item, fmt.Sprintf("%s[%d]", prefix, i), segments[1:])...)
+	}
+	return missing
+}
+
+// joinPath appends key to a dotted document path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// SetGeneralFormatPattern replaces the pattern every string value is checked
+// against, by default ^[ @/=a-zA-Z0-9\.\-_,:()']*$, which admits the
+// punctuation of names and addresses such as "12, M.G. Road (West)". It is meant to be called at
+// startup; an invalid pattern returns an error and leaves the current one in place.
+func SetGeneralFormatPattern(pattern string) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	generalFormatRegex = re
+	return nil
+}
+
+// expectedTypes holds the JSON types set with SetExpectedType, keyed by field name
+var expectedTypes = map[string]FieldType{}
+
+// SetExpectedType requires the values of fields named key to have JSON type t,
+// e.g. TypeBool for "is_active". A value of the wrong type is reported and not
+// validated any further. Null values are treated as absent and not checked.
+func SetExpectedType(key string, t FieldType) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	expectedTypes[key] = t
+}
+
+// numericRange is an inclusive bound set with SetNumericRange
+type numericRange struct {
+	min, max float64
+}
+
+var numericRanges = map[string]numericRange{}
+
+// SetNumericRange requires numeric values of fields named key, including the
+// numeric elements of an array under key, to lie within [min, max].
+func SetNumericRange(key string, min, max float64) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	numericRanges[key] = numericRange{min: min, max: max}
+}
+
+// validateNumericRange checks a numeric value against the range registered for key
+func validateNumericRange(key string, value interface{}) error {
+	limits, ok := numericRanges[key]
+	if !ok {
+		return nil
+	}
+	number, ok := toFloat64(value)
+	if !ok {
+		return nil
+	}
+	if number < limits.min || number > limits.max {
+		return messageError("out_of_range", key, limits.min, limits.max)
+	}
+	return nil
+}
+
+// toFloat64 converts the numeric types isValidGeneralFormat accepts to float64.
+// Request bodies decode numbers as json.Number.
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func isValidGeneralFormat(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		// Check if the string matches the general format, by default
+		// alphanumeric, space and @ / = . - _ , : ( ) '
+		return generalFormatRegex.MatchString(v)
+	case int, int32, int64, float32, float64, json.Number:
+		// Numeric types, allow any numeric format
+		return true
+	default:
+		// For other types (arrays, etc.), currently assume valid
+		return true
+	}
+}
+
+// invalidFormatError describes a value failing the general format check.
+// Values of redacted fields are masked so they never reach the logs.
+func invalidFormatError(key string, value interface{}) error {
+	if isRedactedKey(key) {
+		value = redactedValue
+	}
+	return messageError("invalid_format", value)
+}
+
+// getStringValue attempts to convert the input value to string
+func getStringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String() // Keep the number exactly as sent
+	}
+	return fmt.Sprintf("%v", value) // Fallback to formatting as string
+}
+
+// addValidationError appends err to the slice as a failure of the field at
+// path. Errors without a code, e.g. from custom validators, get "invalid_value".
+func addValidationError(validationErrors *[]FieldError, path string, err error) {
+	code := "invalid_value"
+	var coded *codedError
+	if errors.As(err, &coded) {
+		code = coded.code
+	}
+	*validationErrors = append(*validationErrors, FieldError{Field: path, Code: code, Message: err.Error(), err: err})
+}
+
+// customValidators holds validators added through RegisterValidator, keyed by field name
+var customValidators = map[string]func(value string) error{}
+
+// RegisterValidator adds a validator for fields named key. A registered
+// validator takes precedence over the built-in rules: when one exists for a
+// key, the built-in check for that key is not run. Registering the same key
+// again replaces the previous validator.
+func RegisterValidator(key string, fn func(value string) error) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	customValidators[key] = fn
+}
+
+// RegisterRegexValidator adds a validator for fields named key rejecting
+// values that do not match pattern with message, e.g. loaded from a config
+// file at startup. It is RegisterValidator with a pattern compiled once; if
+// pattern does not compile an error is returned and nothing is registered.
+func RegisterRegexValidator(key, pattern, message string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	RegisterValidator(key, func(value string) error {
+		if !re.MatchString(value) {
+			return errors.New(message)
+		}
+		return nil
+	})
+	return nil
+}
+
+// sanitizers holds the functions added through RegisterSanitizer, keyed by field name
+var sanitizers = map[string]func(value string) string{}
+
+// RegisterSanitizer normalizes the string values of fields named key in JSON
+// bodies, including the string elements of an array under key, e.g. to
+// lowercase emails. Sanitizers run before validation, rewriting the decoded
+// data in place, so the rules check the sanitized value and downstream
+// handlers get it in jsonData. Registering the same key again replaces the
+// previous sanitizer.
+func RegisterSanitizer(key string, fn func(value string) string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	sanitizers[key] = fn
+}
+
+// sanitizeData applies the registered sanitizers to jsonData in place
+func sanitizeData(jsonData interface{}) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if len(sanitizers) > 0 {
+		sanitizeValue("", jsonData)
+	}
+}
+
+// sanitizeValue applies the registered sanitizers to the value found under
+// key and to every field below it, returning the sanitized value
+func sanitizeValue(key string, input interface{}) interface{} {
+	switch v := input.(type) {
+	case map[string]interface{}:
+		for field, value := range v {
+			v[field] = sanitizeValue(field, value)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeValue(key, item)
+		}
+	case string:
+		if fn, ok := sanitizers[key]; ok {
+			return fn(v)
+		}
+	}
+	return input
+}
+
+// pathValidators holds validators added through RegisterPathValidator, keyed by dotted path
+var pathValidators = map[string]func(value string) error{}
+
+// RegisterPathValidator adds a validator for the field at a dotted path, e.g.
+// "user.id", without array indexes, so "items.sku" matches every element of
+// items. It takes precedence over validators and built-in rules matched by key
+// name, letting the same key be validated differently in different places.
+func RegisterPathValidator(path string, fn func(value string) error) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	pathValidators[path] = fn
+}
+
+// fieldLength is a rune length constraint set with SetFieldLength
+type fieldLength struct {
+	min, max int
+}
+
+var fieldLengths = map[string]fieldLength{}
+
+// SetFieldLength constrains the length of fields named key to between min and
+// max characters, counted in runes. A max of zero leaves the length unbounded.
+func SetFieldLength(key string, min, max int) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	fieldLengths[key] = fieldLength{min: min, max: max}
+}
+
+// validateFieldLength checks value against the length constraint registered for key
+func validateFieldLength(key, value string) error {
+	limits, ok := fieldLengths[key]
+	if !ok {
+		return nil
+	}
+	length := utf8.RuneCountInString(value)
+	if length < limits.min {
+		return messageError("too_short", key)
+	}
+	if limits.max > 0 && length > limits.max {
+		return messageError("too_long", key)
+	}
+	return nil
+}
+
+// fieldAliases maps normalized field names to the built-in field they are validated as
+var fieldAliases = map[string]string{
+	"phonenumber":   "mobile",
+	"mobilenumber":  "mobile",
+	"mobileno":      "mobile",
+	"contactnumber": "mobile",
+	"emailaddress":  "email",
+	"emailid":       "email",
+	"pannumber":     "pan",
+	"aadhaarnumber": "aadhaar",
+}
+
+// SetFieldAlias makes fields named alias use the built-in validator of key,
+// e.g. SetFieldAlias("customerPhone", "mobile"). Both names are normalized
+// as described on builtinKey.
+func SetFieldAlias(alias, key string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	fieldAliases[normalizeKey(alias)] = normalizeKey(key)
+}
+
+// disabledValidators holds the built-in validators turned off with DisableValidator
+var disabledValidators = map[string]bool{}
+
+// DisableValidator turns off the built-in validator of fields named key, e.g.
+// "pan", so they only get the checks of any other field: the general format,
+// the blocklist and the length, enum and range rules. key is normalized and
+// resolved like field names, so disabling "mobile" also covers "mobile_number",
+// but "phone", which has its own entry, must be disabled separately.
+// Validators added with RegisterValidator still apply.
+func DisableValidator(key string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	disabledValidators[builtinKey(key)] = true
+}
+
+// normalizeKey lowercases key and strips '_', '-' and spaces so "phoneNumber",
+// "phone_number" and "PHONE-NUMBER" compare equal
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', ' ':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// builtinKey resolves a field name to the name the built-in validators are
+// matched on: the normalized key, or its alias target. Custom validators and
+// other per-key rules are matched on the exact field name.
+func builtinKey(key string) string {
+	key = normalizeKey(key)
+	if target, ok := fieldAliases[key]; ok {
+		return target
+	}
+	return key
+}
+
+// allowedValues is an enum constraint set with SetAllowedValues
+type allowedValues struct {
+	values     map[string]bool
+	ignoreCase bool
+}
+
+var enumFields = map[string]allowedValues{}
+
+// SetAllowedValues restricts fields named key to one of values, compared
+// case-sensitively.
+func SetAllowedValues(key string, values []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	setAllowedValues(key, values, false)
+}
+
+// SetAllowedValuesIgnoreCase restricts fields named key to one of values,
+// ignoring case.
+func SetAllowedValuesIgnoreCase(key string, values []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	setAllowedValues(key, values, true)
+}
+
+func setAllowedValues(key string, values []string, ignoreCase bool) {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		if ignoreCase {
+			value = strings.ToLower(value)
+		}
+		set[value] = true
+	}
+	enumFields[key] = allowedValues{values: set, ignoreCase: ignoreCase}
+}
+
+// validateAllowedValue checks value against the allowed values registered for key
+func validateAllowedValue(key, value string) error {
+	enum, ok := enumFields[key]
+	if !ok {
+		return nil
+	}
+	if enum.ignoreCase {
+		value = strings.ToLower(value)
+	}
+	if !enum.values[value] {
+		return messageError("invalid_enum", key)
+	}
+	return nil
+}
+
+// builtinFormats are the normalized field names with a built-in validator
+// in the switch of validateField, which must be kept in step with it
+var builtinFormats = map[string]bool{
+	"otp": true, "mobile": true, "contact": true, "phone": true, "pan": true,
+	"email": true, "aadhaar": true, "uid": true, "ifsc": true, "ifsccode": true,
+	"gstin": true, "gst": true, "date": true, "dob": true, "expiry": true,
+	"url": true, "website": true, "callbackurl": true, "uuid": true,
+	"requestid": true, "correlationid": true, "card": true, "cardnumber": true,
+	"cc": true, "password": true, "country": true, "countrycode": true,
+	"currency": true, "currencycode": true, "vehicle": true,
+	"vehiclenumber": true, "regno": true, "iban": true, "dl": true,
+	"license": true, "licence": true, "drivinglicense": true,
+	"drivinglicence": true, "passport": true, "passportno": true,
+	"passportnumber": true, "lat": true, "latitude": true, "lng": true,
+	"lon": true, "longitude": true, "timestamp": true, "datetime": true,
+	"createdat": true, "updatedat": true, "amount": true, "price": true,
+	"total": true, "percentage": true, "percent": true, "discount": true,
+	"rate": true, "hsn": true, "hsncode": true, "sac": true, "saccode": true,
+	"duration": true, "ttl": true, "vpa": true, "upi": true, "upiid": true,
+	"version": true, "appversion": true,
+}
+
+// validateField validates a field and appends errors, reported against path, to the provided slice
+func validateField(cfg Config, key, path, value string, validationErrors *[]FieldError) {
+	if err := validateFieldLength(key, value); err != nil {
+		addValidationError(validationErrors, path, err)
+	}
+	if err := validateAllowedValue(key, value); err != nil {
+		addValidationError(validationErrors, path, err)
+	}
+	if fn, ok := pathValidators[arrayIndexRegex.ReplaceAllString(path, "")]; ok {
+		if err := fn(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	if fn, ok := cfg.Validators[key]; ok {
+		if err := fn(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	if fn, ok := customValidators[key]; ok {
+		if err := fn(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	name := builtinKey(key)
+	if disabledValidators[name] {
+		return
+	}
+	if pincodeKeys[name] {
+		if err := validatePincodeFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	if base64Keys[name] {
+		if err := validateBase64Format(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	if hexKeys[name] {
+		if err := validateHexFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	if booleanKeys[name] {
+		if err := validateBooleanStringFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+		return
+	}
+	switch name {
+	case "otp":
+		if err := validateOTP(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "mobile", "contact", "phone":
+		if err := validateMobileFormat(cfg.MobileMode, value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "pan":
+		if err := validatePanFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "email":
+		if err := validateEmailFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "aadhaar", "uid":
+		if err := validateAadhaarFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "ifsc", "ifsccode":
+		if err := validateIFSCFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "gstin", "gst":
+		if err := validateGSTINFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "date", "dob", "expiry":
+		if err := validateDateFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "url", "website", "callbackurl":
+		if err := validateURLFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "uuid", "requestid", "correlationid":
+		if err := validateUUIDFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "card", "cardnumber", "cc":
+		if err := validateCardFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "password":
+		if err := validatePasswordFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "country", "countrycode":
+		if err := validateCountryCodeFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "currency", "currencycode":
+		if err := validateCurrencyCodeFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "vehicle", "vehiclenumber", "regno":
+		if err := validateVehicleNumberFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "iban":
+		if err := validateIBANFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "dl", "license", "licence", "drivinglicense", "drivinglicence":
+		if err := validateDrivingLicenseFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "passport", "passportno", "passportnumber":
+		if err := validatePassportFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "lat", "latitude":
+		if err := validateLatitude(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "lng", "lon", "longitude":
+		if err := validateLongitude(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "timestamp", "datetime", "createdat", "updatedat":
+		if err := validateDateTimeFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "amount", "price", "total":
+		if err := validateAmountFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "percentage", "percent", "discount", "rate":
+		if err := validatePercentageFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "hsn", "hsncode":
+		if err := validateHSNFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "sac", "saccode":
+		if err := validateSACFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "duration", "ttl":
+		if err := validateDurationFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "vpa", "upi", "upiid":
+		if err := validateUPIFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	case "version", "appversion":
+		if err := validateSemVerFormat(value); err != nil {
+			addValidationError(validationErrors, path, err)
+		}
+	default:
+		if !cfg.TargetedValidation && idKeyRegex.MatchString(key) {
+			if err := validateIDFormat(value); err != nil {
+				addValidationError(validationErrors, path, err)
+			}
+		}
+	}
+}
+
+// MobileMode selects the phone number format accepted for mobile fields
+type MobileMode string
+
+const (
+	// MobileModeIndian accepts 10 digit Indian mobile numbers, the default
+	MobileModeIndian MobileMode = "indian"
+	// MobileModeE164 accepts E.164 numbers: an optional leading + and up to 15 digits
+	MobileModeE164 MobileMode = "e164"
+)
+
+var mobileMode = MobileModeIndian
+
+// SetMobileMode selects the format mobile, contact and phone fields must use.
+func SetMobileMode(mode MobileMode) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	switch mode {
+	case MobileModeIndian, MobileModeE164:
+		mobileMode = mode
+		return nil
+	default:
+		return fmt.Errorf("unknown mobile mode '%s'", mode)
+	}
+}
+
+// validateMobileFormat validates mobile number format in mode, or in the
+// mode set with SetMobileMode when mode is empty
+func validateMobileFormat(mode MobileMode, mobile string) error {
+	re := mobileRegex
+	if effectiveMobileMode(mode) == MobileModeE164 {
+		re = e164Regex
+	}
+	if !re.MatchString(mobile) {
+		return messageError("invalid_mobile")
+	}
+	return nil
+}
+
+// effectiveMobileMode returns mode, or the mode set with SetMobileMode when mode is empty
+func effectiveMobileMode(mode MobileMode) MobileMode {
+	if mode == "" {
+		return mobileMode
+	}
+	return mode
+}
+
+// validatePanFormat validates PAN card number format
+func validatePanFormat(pan string) error {
+	if !panRegex.MatchString(pan) {
+		return messageError("invalid_pan")
+	}
+	return nil
+}
+
+// validateEmailFormat validates email format using net/mail, accepting
+// plus-addressing and internationalized domain names, followed by a domain sanity check
+func validateEmailFormat(email string) error {
+	invalid := messageError("invalid_email")
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return invalid
+	}
+	at := strings.LastIndex(email, "@")
+	if !isValidEmailDomain(email[at+1:]) {
+		return invalid
+	}
+	return nil
+}
+
+// isValidEmailDomain reports whether domain is a plausible host name: at least
+// two dot separated labels of letters, digits and inner hyphens, and a
+// top-level domain of at least two letters
+func isValidEmailDomain(domain string) bool {
+	if len(domain) > 253 {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+				return false
+			}
+		}
+	}
+	tld := labels[len(labels)-1]
+	if utf8.RuneCountInString(tld) < 2 {
+		return false
+	}
+	for _, r := range tld {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// idKeyRegex matches the field names treated as IDs: "id" as a whole word
+// ("id", "user_id", "id-type") or camel case suffix/prefix ("userId", "idNumber")
+var idKeyRegex = regexp.MustCompile(`^(?:[iI][dD]|.*[_\-][iI][dD]|.*[a-z0-9](?:Id|ID)|[iI][dD][_\-].*|[iI]d[A-Z].*)$`)
+
+// SetIDKeyPattern replaces the pattern matched against field names to decide
+// whether they get the ID format check. An invalid pattern returns an error
+// and leaves the current one in place.
+func SetIDKeyPattern(pattern string) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	idKeyRegex = re
+	return nil
+}
+
+// idMinLength is the minimum length of ID values, set with SetIDRules
+var idMinLength = 1
+
+// SetIDRules sets the characters and minimum length accepted in ID fields.
+// charset is the content of a regular expression character class, e.g.
+// "A-Za-z0-9_-"; the default is "A-Za-z0-9" with a minimum length of 1, so
+// empty IDs are rejected unless minLength is 0. An invalid charset or a
+// negative minLength returns an error and leaves the current rules in place.
+func SetIDRules(charset string, minLength int) error {
+	if charset == "" || minLength < 0 {
+		return fmt.Errorf("invalid ID rules %q, minimum length %d", charset, minLength)
+	}
+	re, err := regexp.Compile("^[" + charset + "]*$")
+	if err != nil {
+		return err
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	idRegex = re
+	idMinLength = minLength
+	return nil
+}
+
+// validateIDFormat validates ID format (alphanumeric by default, see SetIDRules)
+func validateIDFormat(value string) error {
+	if value == "" && idMinLength > 0 {
+		return messageError("invalid_id.empty")
+	}
+	if utf8.RuneCountInString(value) < idMinLength {
+		return messageError("invalid_id.length", idMinLength)
+	}
+	if !idRegex.MatchString(value) {
+		return messageError("invalid_id")
+	}
+	return nil
+}
+
+// SetOTPLength sets the accepted number of OTP digits, 6 by default. Use the
+// same value for min and max to require an exact length.
+func SetOTPLength(min, max int) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if min < 1 || max < min {
+		return fmt.Errorf("invalid OTP length range %d-%d", min, max)
+	}
+	otpRegex = regexp.MustCompile(fmt.Sprintf(`^\d{%d,%d}$`, min, max))
+	return nil
+}
+
+// validateOTP validates OTP format
+func validateOTP(otp string) error {
+	if !otpRegex.MatchString(otp) {
+		return messageError("invalid_otp")
+	}
+	return nil
+}
+
+// validateAadhaarFormat validates a 12 digit Aadhaar number and its Verhoeff check digit
+func validateAadhaarFormat(aadhaar string) error {
+	if !aadhaarRegex.MatchString(aadhaar) || !verhoeffValid(aadhaar) {
+		return messageError("invalid_aadhaar")
+	}
+	return nil
+}
+
+// verhoeffValid reports whether the trailing check digit of digits is correct
+func verhoeffValid(digits string) bool {
+	check := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		check = verhoeffMultiplication[check][verhoeffPermutation[i%8][d]]
+	}
+	return check == 0
+}
+
+// validateIFSCFormat validates RBI IFSC code format
+func validateIFSCFormat(ifsc string) error {
+	if !ifscRegex.MatchString(strings.TrimSpace(ifsc)) {
+		return messageError("invalid_ifsc")
+	}
+	return nil
+}
+
+// validateGSTINFormat validates a 15 character GSTIN: state code, PAN, entity number, 'Z' and checksum
+func validateGSTINFormat(gstin string) error {
+	invalid := messageError("invalid_gstin")
+	if len(gstin) != 15 {
+		return invalid
+	}
+	if gstin[0] < '0' || gstin[0] > '9' || gstin[1] < '0' || gstin[1] > '9' {
+		return invalid
+	}
+	if validatePanFormat(gstin[2:12]) != nil || !gstinSuffixRegex.MatchString(gstin[12:]) {
+		return invalid
+	}
+	if gstinCheckCharacter(gstin[:14]) != gstin[14] {
+		return invalid
+	}
+	return nil
+}
+
+// gstinCheckCharacter computes the GSTIN check character of the first 14
+// characters: each is read as a base 36 digit, every second one doubled, and
+// the base 36 digits of the products summed
+func gstinCheckCharacter(prefix string) byte {
+	const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	sum := 0
+	for i := 0; i < len(prefix); i++ {
+		product := strings.IndexByte(digits, prefix[i]) * (i%2 + 1)
+		sum += product/36 + product%36
+	}
+	return digits[(36-sum%36)%36]
+}
+
+// defaultDateLayouts are the layouts date fields are parsed with unless SetDateLayouts is called
+var defaultDateLayouts = []string{"2006-01-02"}
+
+var dateLayouts = defaultDateLayouts
+
+// SetDateLayouts sets the time.Parse layouts accepted for date fields. A
+// value is valid if it parses with any of them; an empty list restores the
+// default "2006-01-02".
+func SetDateLayouts(layouts []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if len(layouts) == 0 {
+		dateLayouts = defaultDateLayouts
+		return
+	}
+	dateLayouts = append([]string(nil), layouts...)
+}
+
+// validateDateFormat validates a date against the configured layouts
+func validateDateFormat(date string) error {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, date); err == nil {
+			return nil
+		}
+	}
+	return messageError("invalid_date")
+}
+
+// acceptEpoch lets datetime fields hold Unix timestamps, set with SetEpochTimestamps
+var acceptEpoch bool
+
+// SetEpochTimestamps makes datetime fields such as "timestamp" and
+// "created_at" also accept integer Unix times in seconds, as a JSON number
+// or a numeric string. By default only RFC 3339 strings are accepted.
+func SetEpochTimestamps(accept bool) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	acceptEpoch = accept
+}
+
+// validateDateTimeFormat validates an RFC 3339 date and time such as
+// "2024-05-01T10:30:00+05:30", or a Unix time when epochs are accepted
+func validateDateTimeFormat(value string) error {
+	if _, err := time.Parse(time.RFC3339, value); err == nil {
+		return nil
+	}
+	if acceptEpoch {
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return nil
+		}
+	}
+	return messageError("invalid_datetime")
+}
+
+// validateURLFormat validates an absolute http or https URL with a host
+func validateURLFormat(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err != nil {
+		return messageError("invalid_url")
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "javascript", "data":
+		return messageError("disallowed_url_scheme", u.Scheme)
+	default:
+		return messageError("invalid_url.scheme")
+	}
+	if u.Host == "" {
+		return messageError("invalid_url.host")
+	}
+	return nil
+}
+
+// uuidVersion is the UUID version enforced by validateUUIDFormat, 0 accepts any version
+var uuidVersion = 0
+
+// SetUUIDVersion makes UUID fields require the given version (1-8) and the
+// RFC 4122 variant. Zero, the default, accepts any canonical UUID.
+func SetUUIDVersion(version int) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if version < 0 || version > 8 {
+		return fmt.Errorf("invalid UUID version %d", version)
+	}
+	uuidVersion = version
+	return nil
+}
+
+// validateUUIDFormat validates the canonical 8-4-4-4-12 hex UUID format
+func validateUUIDFormat(value string) error {
+	if !uuidRegex.MatchString(value) {
+		return messageError("invalid_uuid")
+	}
+	if uuidVersion != 0 {
+		if value[14] != byte('0'+uuidVersion) || !strings.ContainsRune("89abAB", rune(value[19])) {
+			return messageError("invalid_uuid.version", uuidVersion)
+		}
+	}
+	return nil
+}
+
+// pincodeKeys are the field names validated as PIN codes
+var pincodeKeys = map[string]bool{"pincode": true, "pin": true, "zip": true}
+
+// SetPincodeKeys replaces the field names validated as Indian PIN codes,
+// by default "pincode", "pin" and "zip".
+func SetPincodeKeys(keys []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	pincodeKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		pincodeKeys[normalizeKey(key)] = true
+	}
+}
+
+// validatePincodeFormat validates a 6 digit Indian PIN code
+func validatePincodeFormat(pincode string) error {
+	if !pincodeRegex.MatchString(pincode) {
+		return messageError("invalid_pincode")
+	}
+	return nil
+}
+
+var (
+	// base64Keys are the field names validated as base64, none by default
+	base64Keys = map[string]bool{}
+	// base64Encoding is the alphabet base64 fields are decoded with
+	base64Encoding = base64.StdEncoding
+	// hexKeys are the field names validated as hex, none by default
+	hexKeys = map[string]bool{}
+)
+
+// SetBase64Keys replaces the field names validated as base64, e.g. "token".
+// Values are decoded with the standard alphabet, or the URL-safe one when
+// urlSafe is set; padding is optional. Base64 fields skip the general format
+// check, whose default pattern rejects '+'.
+func SetBase64Keys(keys []string, urlSafe bool) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	base64Keys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		base64Keys[normalizeKey(key)] = true
+	}
+	base64Encoding = base64.StdEncoding
+	if urlSafe {
+		base64Encoding = base64.URLEncoding
+	}
+}
+
+// SetHexKeys replaces the field names validated as hex, e.g. "signature".
+func SetHexKeys(keys []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	hexKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		hexKeys[normalizeKey(key)] = true
+	}
+}
+
+var (
+	// booleanKeys are the field names validated as booleans, none by default
+	booleanKeys = map[string]bool{}
+	// booleanTokens are the lowercased values accepted for boolean fields
+	booleanTokens = tokenSet([]string{"true", "yes", "1"}, []string{"false", "no", "0"})
+)
+
+// SetBooleanKeys replaces the field names validated as booleans, e.g.
+// "consent", which may then hold a JSON boolean or one of the boolean tokens.
+func SetBooleanKeys(keys []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	booleanKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		booleanKeys[normalizeKey(key)] = true
+	}
+}
+
+// SetBooleanTokens replaces the strings accepted as true and false by boolean
+// fields, compared ignoring case, by default "true", "yes" and "1" and
+// "false", "no" and "0". JSON booleans are always accepted.
+func SetBooleanTokens(truthy, falsy []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	booleanTokens = tokenSet(truthy, falsy)
+}
+
+// tokenSet returns the lowercased truthy and falsy tokens, along with the
+// JSON booleans, as a set
+func tokenSet(truthy, falsy []string) map[string]bool {
+	tokens := map[string]bool{"true": true, "false": true}
+	for _, token := range append(append([]string(nil), truthy...), falsy...) {
+		tokens[strings.ToLower(token)] = true
+	}
+	return tokens
+}
+
+// validateBooleanStringFormat checks that value is one of the boolean tokens
+func validateBooleanStringFormat(value string) error {
+	if !booleanTokens[strings.ToLower(value)] {
+		return messageError("invalid_boolean")
+	}
+	return nil
+}
+
+// validateBase64Format checks that value decodes as base64, with or without padding
+func validateBase64Format(value string) error {
+	encoding := base64Encoding
+	if !strings.HasSuffix(value, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	if _, err := encoding.DecodeString(value); err != nil {
+		return messageError("invalid_base64")
+	}
+	return nil
+}
+
+// validateHexFormat checks that value decodes as hex, so it has an even number of digits
+func validateHexFormat(value string) error {
+	if _, err := hex.DecodeString(value); err != nil {
+		return messageError("invalid_hex")
+	}
+	return nil
+}
+
+// validateCardFormat validates a 13 to 19 digit card number, ignoring spaces
+// and dashes, with the Luhn checksum. The error never includes the number.
+func validateCardFormat(card string) error {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(card)
+	if len(digits) < 13 || len(digits) > 19 {
+		return messageError("invalid_card")
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := digits[len(digits)-1-i]
+		if d < '0' || d > '9' {
+			return messageError("invalid_card")
+		}
+		n := int(d - '0')
+		if i%2 == 1 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	if sum%10 != 0 {
+		return messageError("invalid_card")
+	}
+	return nil
+}
+
+// redactedValue replaces the values of redacted fields
+const redactedValue = "***"
+
+// defaultRedactedFields are always masked: card numbers and passwords must never be logged
+var defaultRedactedFields = []string{"card", "card_number", "cc", "password"}
+
+// redactedFields holds the normalized names of fields whose values are masked
+var redactedFields = redactedFieldSet(nil)
+
+// SetRedactedFields sets the fields whose values are replaced with "***" in
+// the body stored under "reqBody" and in logged validation errors, e.g. otp
+// or password. Names are matched like the built-in validators, ignoring case
+// and separators. Card number and password fields are always redacted. Only JSON bodies
+// are rewritten; the stored body is then re-encoded from the decoded data.
+func SetRedactedFields(fields []string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	redactedFields = redactedFieldSet(fields)
+}
+
+func redactedFieldSet(fields []string) map[string]bool {
+	set := make(map[string]bool, len(defaultRedactedFields)+len(fields))
+	for _, field := range append(append([]string(nil), defaultRedactedFields...), fields...) {
+		set[builtinKey(field)] = true
+	}
+	return set
+}
+
+// isRedactedKey reports whether the value of field key must be masked
+func isRedactedKey(key string) bool {
+	return redactedFields[builtinKey(key)]
+}
+
+// RedactBody returns body as a string with redacted field values masked.
+// The raw body is returned unchanged when it holds no redacted field.
+func RedactBody(body []byte, jsonData interface{}) string {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	if jsonData == nil {
+		return string(body)
+	}
+	redacted, changed := redactValue(jsonData)
+	if !changed {
+		return string(body)
+	}
+	encoded, err := json.Marshal(redacted)
+	if err != nil {
+		return redactedValue
+	}
+	return string(encoded)
+}
+
+// redactValue returns a copy of value with redacted fields masked, and
+// whether anything was masked. value itself is not modified.
+func redactValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		changed := false
+		for key, item := range v {
+			if isRedactedKey(key) && item != nil {
+				copied[key] = redactedValue
+				changed = true
+				continue
+			}
+			var itemChanged bool
+			copied[key], itemChanged = redactValue(item)
+			changed = changed || itemChanged
+		}
+		return copied, changed
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		changed := false
+		for i, item := range v {
+			var itemChanged bool
+			copied[i], itemChanged = redactValue(item)
+			changed = changed || itemChanged
+		}
+		return copied, changed
+	default:
+		return value, false
+	}
+}
+
+// PasswordPolicy describes the rules password fields must satisfy
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// passwordPolicy is the policy enforced by validatePasswordFormat
+var passwordPolicy = PasswordPolicy{
+	MinLength:     8,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireDigit:  true,
+	RequireSymbol: true,
+}
+
+// SetPasswordPolicy replaces the password policy. The default requires at
+// least 8 characters with an upper case letter, a lower case letter, a digit
+// and a symbol.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	passwordPolicy = policy
+}
+
+// validatePasswordFormat validates password against the configured policy,
+// describing the first rule that fails
+func validatePasswordFormat(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	switch {
+	case utf8.RuneCountInString(password) < passwordPolicy.MinLength:
+		return messageError("weak_password.length", passwordPolicy.MinLength)
+	case passwordPolicy.RequireUpper && !hasUpper:
+		return messageError("weak_password.upper")
+	case passwordPolicy.RequireLower && !hasLower:
+		return messageError("weak_password.lower")
+	case passwordPolicy.RequireDigit && !hasDigit:
+		return messageError("weak_password.digit")
+	case passwordPolicy.RequireSymbol && !hasSymbol:
+		return messageError("weak_password.symbol")
+	}
+	return nil
+}
+
+// validateCountryCodeFormat validates an ISO 3166-1 alpha-2 or alpha-3 country code
+func validateCountryCodeFormat(code string) error {
+	if _, ok := countryCodes[code]; ok || alpha3CountryCodes[code] {
+		return nil
+	}
+	return messageError("invalid_country")
+}
+
+// validateCurrencyCodeFormat validates an ISO 4217 currency code
+func validateCurrencyCodeFormat(code string) error {
+	if !currencyCodes[code] {
+		return messageError("invalid_currency")
+	}
+	return nil
+}
+
+// validateVehicleNumberFormat validates an Indian vehicle registration number
+// such as "MH 12 AB 1234": state code, two digit RTO code, an optional one or
+// two letter series and up to four digits. Spaces and dashes are ignored.
+func validateVehicleNumberFormat(number string) error {
+	if !vehicleRegex.MatchString(strings.NewReplacer(" ", "", "-", "").Replace(number)) {
+		return messageError("invalid_vehicle_number")
+	}
+	return nil
+}
+
+// validateIBANFormat validates an IBAN, ignoring spaces: an ISO country code,
+// two check digits and up to 30 alphanumeric characters, with the ISO 7064
+// mod-97 checksum computed over the rearranged number.
+func validateIBANFormat(iban string) error {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if !ibanRegex.MatchString(iban) {
+		return messageError("invalid_iban")
+	}
+	if _, ok := countryCodes[iban[:2]]; !ok {
+		return messageError("invalid_iban")
+	}
+	// Move the country code and check digits to the end and read letters as
+	// 10 to 35; the number is then valid when it leaves remainder 1 mod 97
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' {
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	if remainder != 1 {
+		return messageError("invalid_iban.checksum")
+	}
+	return nil
+}
+
+// validateDrivingLicenseFormat validates an Indian driving licence number such
+// as "MH12 20110012345": state code, two digit RTO code, year of issue and a 7
+// digit sequence. Spaces and dashes are ignored.
+func validateDrivingLicenseFormat(number string) error {
+	number = strings.NewReplacer(" ", "", "-", "").Replace(number)
+	if !drivingLicenseRegex.MatchString(number) || !indianStateCodes[number[:2]] {
+		return messageError("invalid_driving_license")
+	}
+	return nil
+}
+
+// SetPassportPattern replaces the pattern passport numbers must match, by
+// default the Indian format ^[A-Z][A-Z]?[0-9]{7}$: a letter, an optional
+// second letter and 7 digits. An invalid pattern returns an error and leaves
+// the current one in place.
+func SetPassportPattern(pattern string) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	passportRegex = re
+	return nil
+}
+
+// validatePassportFormat validates a passport number
+func validatePassportFormat(passport string) error {
+	if !passportRegex.MatchString(passport) {
+		return messageError("invalid_passport")
+	}
+	return nil
+}
+
+// validateLatitude validates a latitude in degrees, from -90 to 90
+func validateLatitude(value string) error {
+	return validateCoordinate(value, 90, "invalid_latitude")
+}
+
+// validateLongitude validates a longitude in degrees, from -180 to 180
+func validateLongitude(value string) error {
+	return validateCoordinate(value, 180, "invalid_longitude")
+}
+
+// validateCoordinate checks that value is a number from -limit to limit,
+// reporting code otherwise. NaN fails the range check.
+func validateCoordinate(value string, limit float64, code string) error {
+	degrees, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return messageError(code)
+	}
+	if !(degrees >= -limit && degrees <= limit) {
+		return messageError(code + ".range")
+	}
+	return nil
+}
+
+var (
+	// amountDecimals is the number of decimal places allowed in amounts
+	amountDecimals = 2
+	// amountMax is the largest amount allowed, zero when unlimited
+	amountMax float64
+)
+
+// SetAmountRules sets the number of decimal places allowed in amount fields
+// such as "amount" and "price", by default 2, and their maximum, by default
+// unlimited when max is zero. Negative values return an error and leave the
+// current rules in place.
+func SetAmountRules(decimals int, max float64) error {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if decimals < 0 || max < 0 {
+		return fmt.Errorf("invalid amount rules: %d decimals, max %v", decimals, max)
+	}
+	amountDecimals, amountMax = decimals, max
+	return nil
+}
+
+// validateAmountFormat validates a non-negative monetary amount. Numbers are
+// checked in the form they were sent, so 10.999 is caught even though it
+// would round to a valid float; exponents such as 1e3 are rejected.
+func validateAmountFormat(amount string) error {
+	if !amountRegex.MatchString(amount) {
+		if strings.HasPrefix(amount, "-") && amountRegex.MatchString(amount[1:]) {
+			return messageError("invalid_amount.negative")
+		}
+		return messageError("invalid_amount")
+	}
+	if i := strings.IndexByte(amount, '.'); i >= 0 && len(amount)-i-1 > amountDecimals {
+		return messageError("invalid_amount.decimals", amountDecimals)
+	}
+	if amountMax > 0 {
+		if value, err := strconv.ParseFloat(amount, 64); err != nil || value > amountMax {
+			return messageError("invalid_amount.max", amountMax)
+		}
+	}
+	return nil
+}
+
+// percentageRange is the range of percentage fields, up to 100 unless
+// SetPercentageAbove100 lifts the limit
+var percentageRange = numericRange{min: 0, max: 100}
+
+// SetPercentageAbove100 lets percentage fields such as "discount" and "rate"
+// exceed 100, e.g. for markups. They must still be non-negative.
+func SetPercentageAbove100(allow bool) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	percentageRange.max = 100
+	if allow {
+		percentageRange.max = math.MaxFloat64
+	}
+}
+
+// validatePercentageFormat validates a percentage, by default from 0 to 100
+func validatePercentageFormat(value string) error {
+	percentage, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(percentage) || math.IsInf(percentage, 0) {
+		return messageError("invalid_percentage")
+	}
+	if percentage < percentageRange.min {
+		return messageError("invalid_percentage.negative")
+	}
+	if percentage > percentageRange.max {
+		return messageError("invalid_percentage.range")
+	}
+	return nil
+}
+
+// hsnLengths are the digit counts accepted for HSN codes
+var hsnLengths = map[int]bool{4: true, 6: true, 8: true}
+
+// SetHSNLengths replaces the digit counts accepted for HSN codes, by default
+// 4, 6 and 8, e.g. to require the 8 digits of export invoices. An empty list
+// or a count outside 2 to 8 returns an error and keeps the current lengths.
+func SetHSNLengths(lengths []int) error {
+	if len(lengths) == 0 {
+		return errors.New("no HSN code lengths")
+	}
+	set := make(map[int]bool, len(lengths))
+	for _, length := range lengths {
+		if length < 2 || length > 8 {
+			return fmt.Errorf("invalid HSN code length %d", length)
+		}
+		set[length] = true
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	hsnLengths = set
+	return nil
+}
+
+// validateHSNFormat validates a Harmonized System of Nomenclature code used
+// on GST invoices for goods: digits only, in one of the configured lengths
+func validateHSNFormat(hsn string) error {
+	if !hsnLengths[len(hsn)] || strings.Trim(hsn, "0123456789") != "" {
+		return messageError("invalid_hsn")
+	}
+	return nil
+}
+
+// validateSACFormat validates a 6 digit Services Accounting Code, which
+// always falls in chapter 99
+func validateSACFormat(sac string) error {
+	if len(sac) != 6 || !strings.HasPrefix(sac, "99") || strings.Trim(sac, "0123456789") != "" {
+		return messageError("invalid_sac")
+	}
+	return nil
+}
+
+// validateDurationFormat validates an ISO 8601 duration such as
+// "P1Y2M10DT2H30M" or "PT0.5S": designators in order, each at most once,
+// at least one component, a T only before time components, a decimal
+// fraction only on the last component, and weeks only on their own, as in "P2W"
+func validateDurationFormat(duration string) error {
+	invalid := messageError("invalid_duration")
+	rest, ok := strings.CutPrefix(duration, "P")
+	if !ok || rest == "" {
+		return invalid
+	}
+	datePart, timePart, hasTime := strings.Cut(rest, "T")
+	if hasTime && timePart == "" {
+		return invalid
+	}
+	dateUnits, dateFraction, ok := durationComponents(datePart, "YMWD")
+	if !ok || dateFraction && hasTime {
+		return invalid
+	}
+	if strings.Contains(dateUnits, "W") && (len(dateUnits) > 1 || hasTime) {
+		return invalid
+	}
+	if _, _, ok := durationComponents(timePart, "HMS"); !ok {
+		return invalid
+	}
+	return nil
+}
+
+// durationComponents parses the components of one part of a duration, whose
+// designators must appear in the order of units. It returns the designators
+// found and whether the last component had a fraction.
+func durationComponents(part, units string) (string, bool, bool) {
+	var found []byte
+	fraction := false
+	for part != "" {
+		if fraction {
+			// Only the last component may have a fraction
+			return "", false, false
+		}
+		end := strings.IndexFunc(part, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != ','
+		})
+		if end <= 0 {
+			return "", false, false
+		}
+		number, unit := part[:end], part[end]
+		index := strings.IndexByte(units, unit)
+		if index < 0 {
+			return "", false, false
+		}
+		whole, decimals, hasFraction := strings.Cut(strings.ReplaceAll(number, ",", "."), ".")
+		if whole == "" || strings.Trim(whole, "0123456789") != "" ||
+			hasFraction && (decimals == "" || strings.Trim(decimals, "0123456789") != "") {
+			return "", false, false
+		}
+		fraction = hasFraction
+		found = append(found, unit)
+		units = units[index+1:]
+		part = part[end+1:]
+	}
+	return string(found), fraction, true
+}
+
+// validateUPIFormat validates a UPI virtual payment address such as
+// "name@bank": a handle of letters, digits, '.', '-' and '_' starting with a
+// letter or digit, '@', and an alphanumeric provider handle
+func validateUPIFormat(vpa string) error {
+	if !upiRegex.MatchString(vpa) {
+		return messageError("invalid_upi")
+	}
+	return nil
+}
+
+// SemVerRule says whether semantic versions may carry a pre-release or build metadata part
+type SemVerRule string
+
+const (
+	// SemVerOptional accepts versions with or without the part, the default
+	SemVerOptional SemVerRule = "optional"
+	// SemVerRequired rejects versions without the part
+	SemVerRequired SemVerRule = "required"
+	// SemVerForbidden rejects versions with the part
+	SemVerForbidden SemVerRule = "forbidden"
+)
+
+// semVerPreRelease and semVerBuild are the rules set with SetSemVerRules
+var (
+	semVerPreRelease = SemVerOptional
+	semVerBuild      = SemVerOptional
+)
+
+// SetSemVerRules sets whether version fields may, must or must not carry a
+// pre-release part such as "-rc.1" and build metadata such as "+build5", e.g.
+// SetSemVerRules(SemVerForbidden, SemVerForbidden) to accept only releases.
+func SetSemVerRules(preRelease, build SemVerRule) error {
+	for _, rule := range []SemVerRule{preRelease, build} {
+		switch rule {
+		case SemVerOptional, SemVerRequired, SemVerForbidden:
+		default:
+			return fmt.Errorf("unknown semantic version rule '%s'", rule)
+		}
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	semVerPreRelease = preRelease
+	semVerBuild = build
+	return nil
+}
+
+// validateSemVerFormat validates a semantic version 2.0.0 such as "1.2.3" or
+// "2.0.0-rc.1+build5", and its parts against the rules set with SetSemVerRules
+func validateSemVerFormat(version string) error {
+	invalid := messageError("invalid_semver")
+	rest, build, hasBuild := strings.Cut(version, "+")
+	core, preRelease, hasPreRelease := strings.Cut(rest, "-")
+
+	numbers := strings.Split(core, ".")
+	if len(numbers) != 3 {
+		return invalid
+	}
+	for _, number := range numbers {
+		if !semVerNumeric(number) {
+			return invalid
+		}
+	}
+	if hasPreRelease && !semVerIdentifiers(preRelease, true) {
+		return invalid
+	}
+	if hasBuild && !semVerIdentifiers(build, false) {
+		return invalid
+	}
+
+	if err := semVerPart(semVerPreRelease, hasPreRelease, "pre"); err != nil {
+		return err
+	}
+	return semVerPart(semVerBuild, hasBuild, "build")
+}
+
+// semVerNumeric reports whether s is a number without leading zeros
+func semVerNumeric(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == "" && (s == "0" || s[0] != '0')
+}
+
+// semVerIdentifiers reports whether s is a dot-separated list of non-empty
+// alphanumeric and hyphen identifiers. Numeric pre-release identifiers must
+// not have leading zeros, unlike build metadata ones.
+func semVerIdentifiers(s string, preRelease bool) bool {
+	for _, identifier := range strings.Split(s, ".") {
+		if identifier == "" || strings.Trim(identifier, "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-") != "" {
+			return false
+		}
+		if preRelease && strings.Trim(identifier, "0123456789") == "" && !semVerNumeric(identifier) {
+			return false
+		}
+	}
+	return true
+}
+
+// semVerPart applies rule to a version part, named as in the message variants
+func semVerPart(rule SemVerRule, present bool, part string) error {
+	switch {
+	case rule == SemVerRequired && !present:
+		return messageError("invalid_semver.need_" + part)
+	case rule == SemVerForbidden && present:
+		return messageError("invalid_semver.no_" + part)
+	}
+	return nil
+}
@@ -0,0 +1,724 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValidateAadhaarFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		aadhaar string
+		wantErr bool
+	}{
+		{"valid", "234123412346", false},
+		{"valid", "499181036523", false},
+		{"valid", "876543210988", false},
+		{"one digit changed", "234223412346", true},
+		{"one digit changed", "499281036523", true},
+		{"check digit changed", "876543210989", true},
+		{"adjacent digits transposed", "234124312346", true},
+		{"adjacent digits transposed", "499180136523", true},
+		{"adjacent digits transposed", "876542310988", true},
+		{"11 digits", "23412341234", true},
+		{"13 digits", "2341234123467", true},
+		{"leading 1", "134123412346", true},
+		{"non-digits", "23412341234a", true},
+		{"spaces", "2341 2341 2346", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.aadhaar, func(t *testing.T) {
+			if err := validateAadhaarFormat(tt.aadhaar); (err != nil) != tt.wantErr {
+				t.Errorf("validateAadhaarFormat(%q) = %v, want error %v", tt.aadhaar, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateIFSCFormat(t *testing.T) {
+	tests := []struct {
+		ifsc    string
+		wantErr bool
+	}{
+		{"SBIN0001234", false},
+		{"HDFC0ABC123", false},
+		{" ICIC0000001 ", false},
+		{"SBIN1001234", true},
+		{"sbin0001234", true},
+		{"SBI00001234", true},
+		{"SBIN000123", true},
+		{"SBIN00012345", true},
+		{"SBIN0-01234", true},
+	}
+	for _, tt := range tests {
+		if err := validateIFSCFormat(tt.ifsc); (err != nil) != tt.wantErr {
+			t.Errorf("validateIFSCFormat(%q) = %v, want error %v", tt.ifsc, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateGSTINFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		gstin   string
+		wantErr bool
+	}{
+		{"valid", "27AAPFU0939F1ZV", false},
+		{"valid", "29AAGCB7383J1Z4", false},
+		{"valid", "33AAACH7409R1Z8", false},
+		{"check character changed", "27AAPFU0939F1ZW", true},
+		{"entity number changed", "27AAPFU0939F2ZV", true},
+		{"adjacent digits transposed", "27AAPFU9039F1ZV", true},
+		{"state code swapped", "72AAPFU0939F1ZV", true},
+		{"entity number 0", "27AAPFU0939F0Z" + string(gstinCheckCharacter("27AAPFU0939F0Z")), true},
+		{"no Z", "27AAPFU0939F1Y" + string(gstinCheckCharacter("27AAPFU0939F1Y")), true},
+		{"invalid PAN", "27AAPF10939F1Z" + string(gstinCheckCharacter("27AAPF10939F1Z")), true},
+		{"letter state code", "2XAAPFU0939F1ZV", true},
+		{"lower case", "27aapfu0939f1zv", true},
+		{"14 characters", "27AAPFU0939F1Z", true},
+		{"16 characters", "27AAPFU0939F1ZVV", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.gstin, func(t *testing.T) {
+			if err := validateGSTINFormat(tt.gstin); (err != nil) != tt.wantErr {
+				t.Errorf("validateGSTINFormat(%q) = %v, want error %v", tt.gstin, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUUIDFormat(t *testing.T) {
+	versions := map[int]string{
+		1: "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		2: "000003e8-2363-21ef-b200-325096b39f47",
+		3: "a3bb189e-8bf9-3888-9912-ace4e6543002",
+		4: "550e8400-e29b-41d4-a716-446655440000",
+		5: "886313e1-3b8a-5372-9b90-0c9aee199e5d",
+	}
+	tests := []struct {
+		name    string
+		uuid    string
+		wantErr bool
+	}{
+		{"v1", versions[1], false},
+		{"v2", versions[2], false},
+		{"v3", versions[3], false},
+		{"v4", versions[4], false},
+		{"v5", versions[5], false},
+		{"upper case", strings.ToUpper(versions[4]), false},
+		{"mixed case", "550E8400-e29b-41D4-a716-446655440000", false},
+		{"too short", "550e8400-e29b-41d4-a716-44665544000", true},
+		{"too long", "550e8400-e29b-41d4-a716-4466554400000", true},
+		{"missing hyphens", "550e8400e29b41d4a716446655440000", true},
+		{"misplaced hyphens", "550e840-0e29b-41d4-a716-446655440000", true},
+		{"braces", "{550e8400-e29b-41d4-a716-446655440000}", true},
+		{"non-hex", "550e8400-e29b-41d4-a716-44665544000g", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateUUIDFormat(tt.uuid); (err != nil) != tt.wantErr {
+				t.Errorf("validateUUIDFormat(%q) = %v, want error %v", tt.uuid, err, tt.wantErr)
+			}
+		})
+	}
+
+	defer SetUUIDVersion(0)
+	for version := 1; version <= 5; version++ {
+		if err := SetUUIDVersion(version); err != nil {
+			t.Fatal(err)
+		}
+		for other, uuid := range versions {
+			if err := validateUUIDFormat(uuid); (err != nil) != (other != version) {
+				t.Errorf("SetUUIDVersion(%d): validateUUIDFormat(%q) = %v", version, uuid, err)
+			}
+		}
+	}
+}
+
+// validateOne validates a document holding key set to value with cfg and
+// returns the error messages reported
+func validateOne(t *testing.T, cfg Config, key string, value interface{}) []string {
+	t.Helper()
+	validationErrors, err := validateData(context.Background(), cfg, map[string]interface{}{key: value})
+	if err != nil {
+		t.Fatalf("validateData: %v", err)
+	}
+	return errorStrings(validationErrors)
+}
+
+func TestOwnFormatFields(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"password", "Passw0rd!"},
+		{"password", "S3cure#Pass+word"},
+		{"url", "https://example.com/search?q=go&page=2"},
+		{"website", "https://example.com/a%20b"},
+		{"callback_url", "https://example.com/hook?token=abc#done"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			if messages := validateOne(t, Config{}, tt.key, tt.value); len(messages) > 0 {
+				t.Errorf("%s %q rejected: %s", tt.key, tt.value, strings.Join(messages, "; "))
+			}
+		})
+	}
+}
+
+func TestE164SkipsGeneralFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"e164 mobile", Config{MobileMode: MobileModeE164}, "mobile", "+14155552671", false},
+		{"e164 phone", Config{MobileMode: MobileModeE164}, "phone", "+919876543210", false},
+		{"e164 contact", Config{MobileMode: MobileModeE164}, "contact", "+447911123456", false},
+		{"e164 alias", Config{MobileMode: MobileModeE164}, "phone_number", "+14155552671", false},
+		{"e164 invalid", Config{MobileMode: MobileModeE164}, "mobile", "+1-415", true},
+		{"indian plus", Config{}, "mobile", "+919876543210", true},
+		{"indian mobile", Config{}, "mobile", "9876543210", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := validateOne(t, tt.cfg, tt.key, tt.value)
+			if gotErr := len(messages) > 0; gotErr != tt.wantErr {
+				t.Errorf("%s %q: errors %q, want errors %v", tt.key, tt.value, messages, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIDKeyDetection(t *testing.T) {
+	// "my clip" passes the general format check but not the ID check
+	tests := []struct {
+		key    string
+		wantID bool
+	}{
+		{"video", false},
+		{"paid", false},
+		{"valid", false},
+		{"identity", false},
+		{"idea", false},
+		{"id", true},
+		{"ID", true},
+		{"user_id", true},
+		{"order-id", true},
+		{"userId", true},
+		{"accountID", true},
+		{"id_token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			messages := validateOne(t, Config{}, tt.key, "my clip")
+			if gotID := len(messages) > 0; gotID != tt.wantID {
+				t.Errorf("%s validated as ID = %v, want %v (errors %q)", tt.key, gotID, tt.wantID, messages)
+			}
+		})
+	}
+}
+
+func TestNestedValuesUnderBuiltinKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"card object", `{"card":{"number":"4111111111111111","expiry":"2030-01-31"}}`, nil},
+		{"price object", `{"price":{"amount":"10.50","currency":"INR"},"total":{"amount":"12"}}`, nil},
+		{"version object", `{"version":{"major":1,"minor":2}}`, nil},
+		{"country array", `{"country":["IN","US"]}`, nil},
+		{"invalid country element", `{"country":["IN","XX"]}`, []string{"country[1]: invalid country code"}},
+		{"invalid mobile element", `{"mobile":["9876543210","12"]}`, []string{"mobile[1]: invalid mobile number format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validationErrors, err := ValidateBody(context.Background(), Config{}, "application/json", []byte(tt.body))
+			if err != nil {
+				t.Fatalf("ValidateBody() error = %v", err)
+			}
+			if got := errorStrings(validationErrors); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLargeIntegerIDPrecision(t *testing.T) {
+	const id = "9223372036854775807"
+	body := []byte(`{"user_id":` + id + `,"items":[{"order_id":1234567890123456789}]}`)
+	jsonData, validationErrors, err := ValidateBody(context.Background(), Config{}, "application/json", body)
+	if err != nil || len(validationErrors) > 0 {
+		t.Fatalf("ValidateBody() = %v, %v, want no errors", validationErrors, err)
+	}
+	object := jsonData.(map[string]interface{})
+	if got := getStringValue(object["user_id"]); got != id {
+		t.Errorf("user_id = %s, want %s", got, id)
+	}
+	item := object["items"].([]interface{})[0].(map[string]interface{})
+	if got := getStringValue(item["order_id"]); got != "1234567890123456789" {
+		t.Errorf("order_id = %s, want 1234567890123456789", got)
+	}
+}
+
+func TestSetOTPLength(t *testing.T) {
+	defer SetOTPLength(6, 6)
+	tests := []struct {
+		name     string
+		min, max int
+		valid    []string
+		invalid  []string
+	}{
+		{"default", 6, 6, []string{"123456"}, []string{"1234", "12345678", "12345a"}},
+		{"4 digits", 4, 4, []string{"1234", "0000"}, []string{"123", "123456", "12345678"}},
+		{"8 digits", 8, 8, []string{"12345678"}, []string{"1234", "1234567", "123456789"}},
+		{"4 to 8 digits", 4, 8, []string{"1234", "123456", "12345678"}, []string{"123", "123456789", "12 34"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetOTPLength(tt.min, tt.max); err != nil {
+				t.Fatal(err)
+			}
+			for _, otp := range tt.valid {
+				if err := validateOTP(otp); err != nil {
+					t.Errorf("validateOTP(%q) = %v, want nil", otp, err)
+				}
+			}
+			for _, otp := range tt.invalid {
+				if err := validateOTP(otp); err == nil {
+					t.Errorf("validateOTP(%q) = nil, want an error", otp)
+				}
+			}
+		})
+	}
+
+	for _, bounds := range [][2]int{{0, 6}, {8, 4}, {-1, -1}} {
+		if err := SetOTPLength(bounds[0], bounds[1]); err == nil {
+			t.Errorf("SetOTPLength(%d, %d) = nil, want an error", bounds[0], bounds[1])
+		}
+	}
+}
+
+func TestValidateVehicleNumberFormat(t *testing.T) {
+	tests := []struct {
+		number  string
+		wantErr bool
+	}{
+		{"MH12AB1234", false},
+		{"MH 12 AB 1234", false},
+		{"KA-01-A-1", false},
+		{"TN09 1234", false},
+		{"MH1AB1234", true},
+		{"MH12ABC1234", true},
+		{"MH12AB12345", true},
+		{"MH12AB", true},
+		{"mh12ab1234", true},
+		{"12MHAB1234", true},
+	}
+	for _, tt := range tests {
+		if err := validateVehicleNumberFormat(tt.number); (err != nil) != tt.wantErr {
+			t.Errorf("validateVehicleNumberFormat(%q) = %v, want error %v", tt.number, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateIBANFormat(t *testing.T) {
+	checksum := messageError("invalid_iban.checksum").Error()
+	format := messageError("invalid_iban").Error()
+	tests := []struct {
+		name    string
+		iban    string
+		wantErr string
+	}{
+		{"GB", "GB82WEST12345698765432", ""},
+		{"GB with spaces", "GB82 WEST 1234 5698 7654 32", ""},
+		{"DE", "DE89370400440532013000", ""},
+		{"FR", "FR1420041010050500013M02606", ""},
+		{"GB bad checksum", "GB83WEST12345698765432", checksum},
+		{"DE bad checksum", "DE89370400440532013001", checksum},
+		{"FR transposed digits", "FR1420041010050500013M02660", checksum},
+		{"unknown country", "XX82WEST12345698765432", format},
+		{"too short", "GB82", format},
+		{"invalid characters", "GB82-WEST-1234-5698-7654-32", format},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIBANFormat(tt.iban)
+			if got := errorString(err); got != tt.wantErr {
+				t.Errorf("validateIBANFormat(%q) = %q, want %q", tt.iban, got, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errorString returns the message of err, or "" for nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestValidateBodyMixedTypeArray(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"valid scalars", `[1, "abc", true, null, 2.5, -3, 2.5e+3, false]`, nil},
+		{"invalid string element", `[1, "abc", "na@me!"]`, []string{"[2]: Invalid format for value 'na@me!'"}},
+		{"scalars and objects", `[true, {"mobile":"12"}, "ok", {"email":"a@example.com"}]`, []string{"[1].mobile: invalid mobile number format"}},
+		{"nested array", `[null, ["x!", 7], "y!"]`, []string{"[1][0]: Invalid format for value 'x!'", "[2]: Invalid format for value 'y!'"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validationErrors, err := ValidateBody(context.Background(), Config{}, "application/json", []byte(tt.body))
+			if err != nil {
+				t.Fatalf("ValidateBody() error = %v", err)
+			}
+			if got := errorStrings(validationErrors); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDrivingLicenseFormat(t *testing.T) {
+	tests := []struct {
+		number  string
+		wantErr bool
+	}{
+		{"MH12 20110012345", false},
+		{"MH-12-2011-0012345", false},
+		{"KA0119990000001", false},
+		{"OR0220050000001", false},
+		{"ZZ12 20110012345", true},
+		{"XY1220110012345", true},
+		{"MH12 18110012345", true},
+		{"MH12 2011001234", true},
+		{"MH12 201100123456", true},
+		{"mh12 20110012345", true},
+		{"MH", true},
+	}
+	for _, tt := range tests {
+		if err := validateDrivingLicenseFormat(tt.number); (err != nil) != tt.wantErr {
+			t.Errorf("validateDrivingLicenseFormat(%q) = %v, want error %v", tt.number, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePassportFormat(t *testing.T) {
+	tests := []struct {
+		passport string
+		wantErr  bool
+	}{
+		{"A1234567", false},
+		{"AB1234567", false},
+		{"1234567A", true},
+		{"A123456", true},
+		{"A12345678", true},
+		{"a1234567", true},
+		{"ABC1234567", true},
+	}
+	for _, tt := range tests {
+		if err := validatePassportFormat(tt.passport); (err != nil) != tt.wantErr {
+			t.Errorf("validatePassportFormat(%q) = %v, want error %v", tt.passport, err, tt.wantErr)
+		}
+	}
+
+	defer SetPassportPattern(`^[A-Z][A-Z]?[0-9]{7}$`)
+	if err := SetPassportPattern(`^[0-9]{9}$`); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPassportPattern(`[0-9`); err == nil {
+		t.Error("SetPassportPattern accepted an invalid pattern")
+	}
+	if validatePassportFormat("123456789") != nil || validatePassportFormat("A1234567") == nil {
+		t.Error("SetPassportPattern(`^[0-9]{9}$`) did not replace the default pattern")
+	}
+}
+
+// discardLogger is a Logger dropping every message
+type discardLogger struct{}
+
+func (discardLogger) Error(args ...interface{})                 {}
+func (discardLogger) Errorf(format string, args ...interface{}) {}
+
+// TestConcurrentRulesAndValidation changes rules while requests are
+// validated; run it with -race
+func TestConcurrentRulesAndValidation(t *testing.T) {
+	defer SetLogger(nil)
+
+	cfg := DefaultConfig()
+	body := []byte(`{"mobile":"12","race_nickname":"x","items":[{"email":"bad"}]}`)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetLogger(discardLogger{})
+				RegisterValidator("race_nickname", func(value string) error {
+					if len(value) < 2 {
+						return errors.New("too short")
+					}
+					return nil
+				})
+				SetMobileMode(MobileModeIndian)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, validationErrors, err := ValidateBody(context.Background(), cfg, "application/json", body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ValidationErrorResponse(cfg, validationErrors, "")
+				ReportValidationErrors(cfg, validationErrors, func(key, value string) {})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNotifyValidationResult(t *testing.T) {
+	var got ValidationResult
+	cfg := Config{OnValidationResult: func(result ValidationResult) { got = result }}
+	validationErrors := []FieldError{
+		{Field: "items[0].sku"},
+		{Field: "items[12].sku"},
+		{Field: "items[3].tags[1]"},
+		{Field: "[2].mobile"},
+		{Field: "email"},
+		{Field: "email"},
+	}
+	NotifyValidationResult(cfg, "/orders", validationErrors, nil)
+
+	want := []string{"items[].sku", "items[].tags[]", "[].mobile", "email"}
+	if got.Route != "/orders" || got.Passed || strings.Join(got.FailedFields, ",") != strings.Join(want, ",") {
+		t.Errorf("result = %+v, want route /orders, failed, fields %q", got, want)
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		value    string
+		wantErr  string
+	}{
+		{"latitude", validateLatitude, "12.9716", ""},
+		{"latitude", validateLatitude, "0", ""},
+		{"latitude", validateLatitude, "90", ""},
+		{"latitude", validateLatitude, "-90", ""},
+		{"latitude", validateLatitude, "90.0000001", "invalid_latitude.range"},
+		{"latitude", validateLatitude, "-90.5", "invalid_latitude.range"},
+		{"latitude", validateLatitude, "1e2", "invalid_latitude.range"},
+		{"latitude", validateLatitude, "NaN", "invalid_latitude.range"},
+		{"latitude", validateLatitude, "north", "invalid_latitude"},
+		{"latitude", validateLatitude, "", "invalid_latitude"},
+		{"longitude", validateLongitude, "77.5946", ""},
+		{"longitude", validateLongitude, "180", ""},
+		{"longitude", validateLongitude, "-180", ""},
+		{"longitude", validateLongitude, "180.0001", "invalid_longitude.range"},
+		{"longitude", validateLongitude, "-181", "invalid_longitude.range"},
+		{"longitude", validateLongitude, "+Inf", "invalid_longitude.range"},
+		{"longitude", validateLongitude, "77,59", "invalid_longitude"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.value, func(t *testing.T) {
+			want := ""
+			if tt.wantErr != "" {
+				want = messageError(tt.wantErr).Error()
+			}
+			if got := errorString(tt.validate(tt.value)); got != want {
+				t.Errorf("%s %q: error %q, want %q", tt.name, tt.value, got, want)
+			}
+		})
+	}
+}
+
+func TestValidatePercentageFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		above   bool
+		wantErr string
+	}{
+		{"0", false, ""},
+		{"100", false, ""},
+		{"99.99", false, ""},
+		{"12.5", false, ""},
+		{"100.01", false, "invalid_percentage.range"},
+		{"-0.01", false, "invalid_percentage.negative"},
+		{"ten", false, "invalid_percentage"},
+		{"NaN", false, "invalid_percentage"},
+		{"Inf", false, "invalid_percentage"},
+		{"150", true, ""},
+		{"1e6", true, ""},
+		{"-1", true, "invalid_percentage.negative"},
+	}
+	defer SetPercentageAbove100(false)
+	for _, tt := range tests {
+		SetPercentageAbove100(tt.above)
+		want := ""
+		if tt.wantErr != "" {
+			want = messageError(tt.wantErr).Error()
+		}
+		if got := errorString(validatePercentageFormat(tt.value)); got != want {
+			t.Errorf("validatePercentageFormat(%q) with above 100 %v: error %q, want %q", tt.value, tt.above, got, want)
+		}
+	}
+}
+
+func TestValidateHSNAndSACFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		code     string
+		wantErr  bool
+	}{
+		{"HSN", validateHSNFormat, "1006", false},
+		{"HSN", validateHSNFormat, "100630", false},
+		{"HSN", validateHSNFormat, "10063010", false},
+		{"HSN", validateHSNFormat, "100", true},
+		{"HSN", validateHSNFormat, "10063", true},
+		{"HSN", validateHSNFormat, "100630101", true},
+		{"HSN", validateHSNFormat, "10O6", true},
+		{"SAC", validateSACFormat, "998314", false},
+		{"SAC", validateSACFormat, "888314", true},
+		{"SAC", validateSACFormat, "99831", true},
+		{"SAC", validateSACFormat, "99831A", true},
+	}
+	for _, tt := range tests {
+		if err := tt.validate(tt.code); (err != nil) != tt.wantErr {
+			t.Errorf("%s %q: error %v, want error %v", tt.name, tt.code, err, tt.wantErr)
+		}
+	}
+
+	defer SetHSNLengths([]int{4, 6, 8})
+	if err := SetHSNLengths([]int{8}); err != nil {
+		t.Fatal(err)
+	}
+	if validateHSNFormat("1006") == nil || validateHSNFormat("10063010") != nil {
+		t.Error("SetHSNLengths([8]) did not restrict HSN codes to 8 digits")
+	}
+	for _, lengths := range [][]int{nil, {9}, {1}} {
+		if err := SetHSNLengths(lengths); err == nil {
+			t.Errorf("SetHSNLengths(%v) = nil, want an error", lengths)
+		}
+	}
+}
+
+func TestValidateDurationFormat(t *testing.T) {
+	tests := []struct {
+		duration string
+		wantErr  bool
+	}{
+		{"P1Y2M10DT2H30M", false},
+		{"PT0.5S", false},
+		{"P2W", false},
+		{"P1D", false},
+		{"P0D", false},
+		{"PT36H", false},
+		{"PT1M", false},
+		{"P1.5D", false},
+		{"P1,5D", false},
+		{"P", true},
+		{"PT", true},
+		{"P1DT", true},
+		{"1Y", true},
+		{"p1d", true},
+		{"P1H", true},
+		{"PT1D", true},
+		{"P1M1Y", true},
+		{"P1Y1Y", true},
+		{"P1.5Y2M", true},
+		{"P1.5DT1H", true},
+		{"P2W1D", true},
+		{"P2WT1H", true},
+		{"P-1D", true},
+		{"P.5D", true},
+		{"P1.D", true},
+	}
+	for _, tt := range tests {
+		if err := validateDurationFormat(tt.duration); (err != nil) != tt.wantErr {
+			t.Errorf("validateDurationFormat(%q) = %v, want error %v", tt.duration, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMalformedJSONOffsets(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing value", `{"a":}`, "at offset 6 (line 1, column 6): invalid character '}' looking for beginning of value"},
+		{"missing colon", `{"a" 1}`, "at offset 6 (line 1, column 6): invalid character '1' after object key"},
+		{"second line", "{\n  \"mobile\": \"9876543210\",\n  \"pan\": x\n}", "at offset 38 (line 3, column 10): invalid character 'x' looking for beginning of value"},
+		{"truncated", `{"a":"b"`, "at offset 8 (line 1, column 8): unexpected EOF"},
+		{"empty array element", `[1,2,,3]`, "at offset 6 (line 1, column 6): invalid character ',' looking for beginning of value"},
+		{"trailing value", `{"a":1} {"b":2}`, "at offset 9 (line 1, column 9): invalid character '{' after top-level value"},
+		{"trailing line", "{\"a\":\"b\"}\n\n]", "at offset 12 (line 3, column 1): invalid character ']' after top-level value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ValidateBody(context.Background(), Config{}, "application/json", []byte(tt.body))
+			if want := ErrMalformedJSON.Error() + " " + tt.want; errorString(err) != want {
+				t.Errorf("ValidateBody(%q) error = %q, want %q", tt.body, errorString(err), want)
+			}
+		})
+	}
+}
+
+func TestValidateBodyStreamOffsets(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing element", `[{"a":1},,{"a":2}]`, "at offset 10: invalid character ',' looking for beginning of value"},
+		{"truncated", `[{"a":1},{"a":`, "at offset 14: unexpected EOF"},
+		{"trailing value", `[{"a":1}] {}`, "at offset 11: invalid character after top-level value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateBodyStream(context.Background(), Config{}, "application/json", strings.NewReader(tt.body))
+			if want := ErrMalformedJSON.Error() + " " + tt.want; errorString(err) != want {
+				t.Errorf("ValidateBodyStream(%q) error = %q, want %q", tt.body, errorString(err), want)
+			}
+		})
+	}
+}
+
+func TestValidateUPIFormat(t *testing.T) {
+	tests := []struct {
+		vpa     string
+		wantErr bool
+	}{
+		{"name@bank", false},
+		{"john.doe-1_x@okhdfcbank", false},
+		{"98765@ybl", false},
+		{"@bank", true},
+		{"n@bank", true},
+		{".name@bank", true},
+		{"name@", true},
+		{"name@1bank", true},
+		{"name@bank.com", true},
+		{"name bank@ybl", true},
+		{"name@@bank", true},
+	}
+	for _, tt := range tests {
+		if err := validateUPIFormat(tt.vpa); (err != nil) != tt.wantErr {
+			t.Errorf("validateUPIFormat(%q) = %v, want error %v", tt.vpa, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package echovalidator adapts the validation core to labstack/echo v4. It
+// depends only on the core package, so echo applications do not pull in gin.
+// Rules are configured through the core package setters.
+package echovalidator
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sanketj85/requestvalidator/core"
+)
+
+// ValidateRequest validates request bodies for echo handlers using the
+// default configuration.
+func ValidateRequest() echo.MiddlewareFunc {
+	return ValidateRequestWithConfig(core.DefaultConfig())
+}
+
+// ValidateRequestWithConfig returns an echo middleware applying the same rules
+// as the gin ValidateRequestWithConfig. Failures are returned as an
+// *echo.HTTPError carrying the usual ResponseBody, so echo's default error
+// handler writes the same JSON as the gin middleware. The body is restored for
+// downstream handlers, and the redacted body and decoded data are stored in
+// the context under "reqBody" and "jsonData".
+func ValidateRequestWithConfig(cfg core.Config) echo.MiddlewareFunc {
+	cfg = cfg.WithDefaults()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if core.IsSkippedPath(routePath(c)) || core.IsTrustedRequest(cfg, c.Request().Header.Get, c.Request().RemoteAddr) {
+				return next(c)
+			}
+			body, err := core.ReadBody(c.Response(), c.Request(), cfg.MaxBodySize)
+			if err != nil {
+				core.NotifyValidationResult(cfg, routePath(c), nil, err)
+				return echo.NewHTTPError(http.StatusBadRequest, core.ReadErrorMessage(err))
+			}
+
+			if err := core.CheckEmptyBody(cfg, c.Request().Method, body); err != nil {
+				core.NotifyValidationResult(cfg, routePath(c), nil, err)
+				return responseError(c, core.FailureResponse(cfg, err))
+			}
+			jsonData, validationErrors, err := core.ValidateBody(c.Request().Context(), cfg, c.Request().Header.Get(echo.HeaderContentType), body)
+			core.NotifyValidationResult(cfg, routePath(c), validationErrors, err)
+			if err != nil {
+				return responseError(c, core.FailureResponse(cfg, err))
+			}
+			if len(validationErrors) > 0 {
+				if !cfg.ReportOnly {
+					return responseError(c, core.ValidationErrorResponse(cfg, validationErrors, core.Locale(cfg, c.Request().Header.Get("Accept-Language"))))
+				}
+				core.ReportValidationErrors(cfg, validationErrors, c.Response().Header().Set)
+			}
+
+			c.Set("reqBody", core.RedactBody(body, jsonData))
+			c.Set("jsonData", jsonData)
+			return next(c)
+		}
+	}
+}
+
+// responseError converts a failure response into an *echo.HTTPError. The
+// Content-Type of problem details is set here, as echo's default error
+// handler keeps a Content-Type already present.
+func responseError(c echo.Context, response core.ResponseBody) error {
+	if contentType := response.ContentType(); contentType != echo.MIMEApplicationJSON {
+		c.Response().Header().Set(echo.HeaderContentType, contentType+"; charset=utf-8")
+	}
+	return echo.NewHTTPError(response.StatusCode, response)
+}
+
+// routePath returns the matched route pattern, or the request path when no route matched
+func routePath(c echo.Context) string {
+	if path := c.Path(); path != "" {
+		return path
+	}
+	return c.Request().URL.Path
+}
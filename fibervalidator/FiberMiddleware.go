@@ -0,0 +1,85 @@
+// Package fibervalidator adapts the validation core to gofiber/fiber v2. It
+// depends only on the core package, so fiber applications do not pull in gin.
+// Rules are configured through the core package setters.
+package fibervalidator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sanketj85/requestvalidator/core"
+)
+
+// ValidateRequest validates request bodies for fiber handlers using the
+// default configuration.
+func ValidateRequest() fiber.Handler {
+	return ValidateRequestWithConfig(core.DefaultConfig())
+}
+
+// ValidateRequestWithConfig returns a fiber handler applying the same rules as
+// the gin ValidateRequestWithConfig. Failures are returned as a *fiber.Error
+// with the configured status, so the app's error handler writes the response,
+// except that problem details, with cfg.ProblemDetails set, are written
+// directly. cfg.MaxBodySize caps the body read by fiber and its decompressed
+// size, so the app's BodyLimit should not be lower. On success the redacted
+// body and decoded data are stored in c.Locals under "reqBody" and "jsonData".
+func ValidateRequestWithConfig(cfg core.Config) fiber.Handler {
+	cfg = cfg.WithDefaults()
+	return func(c *fiber.Ctx) error {
+		// Middleware mounted with app.Use does not see the route pattern, so
+		// skip paths are matched against the request path as for net/http
+		if core.IsSkippedPath(c.Path()) || core.IsTrustedRequest(cfg, header(c), c.Context().RemoteIP().String()) {
+			return c.Next()
+		}
+		// fiber reads the body before any handler, capped by the app's
+		// BodyLimit, so the size limit and decoding are applied to the raw body
+		body, err := core.DecodeBody(c.Get(fiber.HeaderContentEncoding), c.BodyRaw(), cfg.MaxBodySize)
+		if err != nil {
+			err = fmt.Errorf("%w: %w", core.ErrReadBody, err)
+			core.NotifyValidationResult(cfg, c.Path(), nil, err)
+			return responseError(c, core.FailureResponse(cfg, err))
+		}
+
+		if err := core.CheckEmptyBody(cfg, c.Method(), body); err != nil {
+			core.NotifyValidationResult(cfg, c.Path(), nil, err)
+			return responseError(c, core.FailureResponse(cfg, err))
+		}
+		jsonData, validationErrors, err := core.ValidateBody(c.UserContext(), cfg, c.Get(fiber.HeaderContentType), body)
+		core.NotifyValidationResult(cfg, c.Path(), validationErrors, err)
+		if err != nil {
+			return responseError(c, core.FailureResponse(cfg, err))
+		}
+		if len(validationErrors) > 0 {
+			if !cfg.ReportOnly {
+				return responseError(c, core.ValidationErrorResponse(cfg, validationErrors, core.Locale(cfg, c.Get(fiber.HeaderAcceptLanguage))))
+			}
+			core.ReportValidationErrors(cfg, validationErrors, c.Set)
+		}
+
+		c.Locals("reqBody", core.RedactBody(body, jsonData))
+		c.Locals("jsonData", jsonData)
+		return c.Next()
+	}
+}
+
+// header returns the request header lookup of c
+func header(c *fiber.Ctx) func(key string) string {
+	return func(key string) string {
+		return c.Get(key)
+	}
+}
+
+// responseError converts a failure response into a *fiber.Error, appending the
+// errors listed when ReturnErrors is set to the message. Problem details are
+// written directly instead, since fiber's error handler writes plain text.
+func responseError(c *fiber.Ctx, response core.ResponseBody) error {
+	if contentType := response.ContentType(); contentType != fiber.MIMEApplicationJSON {
+		return c.Status(response.StatusCode).JSON(response, contentType)
+	}
+	message := response.Message
+	if len(response.Errors) > 0 {
+		message += ": " + strings.Join(response.Errors, "; ")
+	}
+	return fiber.NewError(response.StatusCode, message)
+}
@@ -0,0 +1,71 @@
+package fibervalidator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sanketj85/requestvalidator/core"
+)
+
+// gzipped returns body compressed with gzip
+func gzipped(body string) string {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(body))
+	zw.Close()
+	return compressed.String()
+}
+
+func TestValidateRequest(t *testing.T) {
+	small := core.DefaultConfig()
+	small.MaxBodySize = 64
+	large := `{"name":"` + strings.Repeat("a", 1000) + `"}`
+
+	tests := []struct {
+		name        string
+		cfg         core.Config
+		body        string
+		gzip        bool
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "valid", cfg: core.DefaultConfig(), body: `{"mobile":"9876543210"}`, wantStatus: http.StatusOK},
+		{name: "invalid field", cfg: core.DefaultConfig(), body: `{"mobile":"12"}`, wantStatus: http.StatusUnprocessableEntity, wantMessage: "invalid request"},
+		{name: "validation status", cfg: core.Config{ValidationStatus: http.StatusBadRequest}, body: `{"mobile":"12"}`, wantStatus: http.StatusBadRequest, wantMessage: "invalid request"},
+		{name: "malformed", cfg: core.DefaultConfig(), body: `{"mobile":}`, wantStatus: http.StatusBadRequest, wantMessage: "malformed JSON body"},
+		{name: "too large", cfg: small, body: large, wantStatus: http.StatusBadRequest, wantMessage: "request body too large"},
+		{name: "gzip", cfg: core.DefaultConfig(), body: gzipped(`{"mobile":"9876543210"}`), gzip: true, wantStatus: http.StatusOK},
+		{name: "gzip too large", cfg: small, body: gzipped(large), gzip: true, wantStatus: http.StatusBadRequest, wantMessage: "request body too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(ValidateRequestWithConfig(tt.cfg))
+			app.Post("/users", func(c *fiber.Ctx) error {
+				return c.SendString("ok")
+			})
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.gzip {
+				req.Header.Set(fiber.HeaderContentEncoding, "gzip")
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(string(body), tt.wantMessage) {
+				t.Errorf("body = %s, want it to contain %q", body, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package jwtvalidator validates the claims of the Bearer JWT sent in the
+// Authorization header with the field rules of the core package, so gateways
+// reject malformed tokens before routing them. Token parsing uses only the
+// standard library and lives in this package, so applications not needing it
+// import neither it nor any JWT dependency.
+package jwtvalidator
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanketj85/requestvalidator/core"
+)
+
+// Config configures the claims middleware
+type Config struct {
+	// Config supplies the options of the claim validation, e.g.
+	// ReturnErrors, TrimSpace and the per-configuration rules
+	core.Config
+	// Key verifies the token signature: a []byte secret for HS256, HS384
+	// and HS512, an *rsa.PublicKey for RS256, RS384 and RS512 or an
+	// *ecdsa.PublicKey for ES256, ES384 and ES512. A nil Key skips signature
+	// verification, e.g. when an upstream service has verified the token.
+	Key interface{}
+	// Optional lets requests without an Authorization header through
+	// unchecked. Malformed headers are rejected either way.
+	Optional bool
+}
+
+// ErrMalformedToken is returned for tokens that cannot be parsed
+var ErrMalformedToken = errors.New("malformed bearer token")
+
+// ErrInvalidSignature is returned for tokens whose signature does not verify with the Key
+var ErrInvalidSignature = errors.New("invalid token signature")
+
+// ErrTokenExpired is returned for tokens past their "exp" claim or before their "nbf" claim
+var ErrTokenExpired = errors.New("token expired or not yet valid")
+
+// ClaimsKey is the gin context key the decoded claims are stored under
+const ClaimsKey = "jwtClaims"
+
+// ValidateClaims returns a middleware validating the claims of the Bearer
+// token without verifying its signature. claims maps claim names to the field
+// keys whose rules apply, e.g. {"mobile": "mobile", "sub": "uuid"}.
+func ValidateClaims(claims map[string]string) gin.HandlerFunc {
+	return ValidateClaimsWithConfig(claims, Config{Config: core.DefaultConfig()})
+}
+
+// ValidateClaimsWithConfig returns the claims middleware using cfg. Missing,
+// malformed, badly signed and expired tokens are rejected with 401, as are
+// tokens with invalid claims; absent claims are not checked. On success the
+// claims are stored in the context under ClaimsKey.
+func ValidateClaimsWithConfig(claims map[string]string, cfg Config) gin.HandlerFunc {
+	cfg.Config = cfg.Config.WithDefaults()
+	cfg.ValidationStatus = http.StatusUnauthorized
+	return func(c *gin.Context) {
+		if core.IsSkippedPath(routePath(c)) {
+			c.Next()
+			return
+		}
+		header := c.GetHeader("Authorization")
+		if header == "" && cfg.Optional {
+			c.Next()
+			return
+		}
+		// The auth scheme is case-insensitive (RFC 7235 §2.1)
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			core.NotifyValidationResult(cfg.Config, routePath(c), nil, ErrMalformedToken)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, core.ResponseBody{StatusCode: http.StatusUnauthorized, Message: "missing bearer token"})
+			return
+		}
+		values, err := ParseToken(strings.TrimSpace(token), cfg.Key)
+		if err != nil {
+			core.NotifyValidationResult(cfg.Config, routePath(c), nil, err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, core.ResponseBody{StatusCode: http.StatusUnauthorized, Message: err.Error()})
+			return
+		}
+
+		validationErrors := core.ValidateHeaderValues(cfg.Config, claims, func(name string) string {
+			return claimString(values[name])
+		})
+		core.NotifyValidationResult(cfg.Config, routePath(c), validationErrors, nil)
+		if len(validationErrors) > 0 {
+			if !cfg.ReportOnly {
+				response := core.ValidationErrorResponse(cfg.Config, validationErrors, core.Locale(cfg.Config, c.GetHeader("Accept-Language")))
+				c.Header("Content-Type", response.ContentType()+"; charset=utf-8")
+				c.AbortWithStatusJSON(response.StatusCode, response)
+				return
+			}
+			core.ReportValidationErrors(cfg.Config, validationErrors, c.Writer.Header().Set)
+		}
+		c.Set(ClaimsKey, values)
+		c.Next()
+	}
+}
+
+// ParseToken decodes the claims of a compact JWT, verifying its signature
+// with key as described for Config.Key unless key is nil, and checking the
+// "exp" and "nbf" claims when present.
+func ParseToken(token string, key interface{}) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg == "" {
+		return nil, ErrMalformedToken
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil || claims == nil {
+		return nil, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if key != nil {
+		if err := verifySignature(header.Alg, parts[0]+"."+parts[1], signature, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkTimes(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// verifySignature checks signature over signed for alg with key. Tokens
+// whose alg does not match the type of key, including "none", are rejected.
+func verifySignature(alg, signed string, signature []byte, key interface{}) error {
+	var algHash crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		algHash = crypto.SHA256
+	case "384":
+		algHash = crypto.SHA384
+	case "512":
+		algHash = crypto.SHA512
+	default:
+		return ErrInvalidSignature
+	}
+
+	switch k := key.(type) {
+	case []byte:
+		if !strings.HasPrefix(alg, "HS") {
+			return ErrInvalidSignature
+		}
+		mac := hmac.New(hashFunc(algHash), k)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") || rsa.VerifyPKCS1v15(k, algHash, digest(algHash, signed), signature) != nil {
+			return ErrInvalidSignature
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") || k.Curve == nil || k.Curve.Params().Name != esCurves[algHash] {
+			return ErrInvalidSignature
+		}
+		// ES signatures are r and s as fixed-size big-endian integers
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest(algHash, signed), r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("jwtvalidator: unsupported key type %T", key)
+	}
+	return nil
+}
+
+// esCurves maps the hash of ES256, ES384 and ES512 to the curve the
+// algorithm requires (RFC 7518 §3.4)
+var esCurves = map[crypto.Hash]string{
+	crypto.SHA256: "P-256",
+	crypto.SHA384: "P-384",
+	crypto.SHA512: "P-521",
+}
+
+// hashFunc returns the constructor of the hash h
+func hashFunc(h crypto.Hash) func() hash.Hash {
+	switch h {
+	case crypto.SHA384:
+		return sha512.New384
+	case crypto.SHA512:
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+// digest hashes signed with h
+func digest(h crypto.Hash, signed string) []byte {
+	hasher := hashFunc(h)()
+	hasher.Write([]byte(signed))
+	return hasher.Sum(nil)
+}
+
+// checkTimes rejects tokens past their "exp" or before their "nbf" claim;
+// either claim, when present, must be a number of seconds since the epoch
+func checkTimes(claims map[string]interface{}, now time.Time) error {
+	for _, name := range []string{"exp", "nbf"} {
+		value, ok := claims[name]
+		if !ok {
+			continue
+		}
+		number, ok := value.(json.Number)
+		if !ok {
+			return ErrMalformedToken
+		}
+		seconds, err := number.Float64()
+		if err != nil {
+			return ErrMalformedToken
+		}
+		at := time.Unix(int64(seconds), 0)
+		if name == "exp" && !now.Before(at) || name == "nbf" && now.Before(at) {
+			return ErrTokenExpired
+		}
+	}
+	return nil
+}
+
+// claimString returns the string form of a scalar claim, or "" for absent,
+// null and non-scalar claims, which are not checked
+func claimString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
+
+// routePath returns the matched route pattern, or the request path when no route matched
+func routePath(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
+	}
+	return c.Request.URL.Path
+}
@@ -0,0 +1,162 @@
+package jwtvalidator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanketj85/requestvalidator/core"
+)
+
+// signToken returns a compact JWT with alg in its header and claims signed
+// with key, the private counterpart of a Config.Key, or unsigned for nil
+func signToken(t *testing.T, alg string, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var algHash crypto.Hash
+	switch alg[len(alg)-3:] {
+	case "256":
+		algHash = crypto.SHA256
+	case "384":
+		algHash = crypto.SHA384
+	default:
+		algHash = crypto.SHA512
+	}
+	var signature []byte
+	switch k := key.(type) {
+	case nil:
+	case []byte:
+		mac := hmac.New(hashFunc(algHash), k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, algHash, digest(algHash, signed)); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest(algHash, signed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	default:
+		t.Fatalf("unsupported key type %T", key)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// tamper replaces the claims of token, keeping its header and signature
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`))
+	return strings.Join(parts, ".")
+}
+
+func mustECDSA(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestParseToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, p384, p521 := mustECDSA(t, elliptic.P256()), mustECDSA(t, elliptic.P384()), mustECDSA(t, elliptic.P521())
+
+	now := time.Now().Unix()
+	claims := map[string]interface{}{"sub": "user-1", "exp": now + 3600}
+	valid := func(alg string, key interface{}) string { return signToken(t, alg, claims, key) }
+
+	tests := []struct {
+		name    string
+		token   string
+		key     interface{}
+		wantErr error
+	}{
+		{name: "HS256", token: valid("HS256", secret), key: secret},
+		{name: "HS384", token: valid("HS384", secret), key: secret},
+		{name: "HS512", token: valid("HS512", secret), key: secret},
+		{name: "HS256 tampered", token: tamper(valid("HS256", secret)), key: secret, wantErr: ErrInvalidSignature},
+		{name: "HS256 wrong secret", token: valid("HS256", []byte("other")), key: secret, wantErr: ErrInvalidSignature},
+		{name: "RS256", token: valid("RS256", rsaKey), key: &rsaKey.PublicKey},
+		{name: "RS512", token: valid("RS512", rsaKey), key: &rsaKey.PublicKey},
+		{name: "RS256 tampered", token: tamper(valid("RS256", rsaKey)), key: &rsaKey.PublicKey, wantErr: ErrInvalidSignature},
+		{name: "ES256", token: valid("ES256", p256), key: &p256.PublicKey},
+		{name: "ES384", token: valid("ES384", p384), key: &p384.PublicKey},
+		{name: "ES512", token: valid("ES512", p521), key: &p521.PublicKey},
+		{name: "ES256 tampered", token: tamper(valid("ES256", p256)), key: &p256.PublicKey, wantErr: ErrInvalidSignature},
+		{name: "ES256 on P-384", token: valid("ES256", p384), key: &p384.PublicKey, wantErr: ErrInvalidSignature},
+		{name: "ES512 on P-256", token: valid("ES512", p256), key: &p256.PublicKey, wantErr: ErrInvalidSignature},
+		{name: "HS256 with RSA key", token: valid("HS256", secret), key: &rsaKey.PublicKey, wantErr: ErrInvalidSignature},
+		{name: "RS256 with secret", token: valid("RS256", rsaKey), key: secret, wantErr: ErrInvalidSignature},
+		{name: "RS256 with EC key", token: valid("RS256", rsaKey), key: &p256.PublicKey, wantErr: ErrInvalidSignature},
+		{name: "none with key", token: signToken(t, "none", claims, nil), key: secret, wantErr: ErrInvalidSignature},
+		{name: "none without key", token: signToken(t, "none", claims, nil)},
+		{name: "expired", token: signToken(t, "HS256", map[string]interface{}{"exp": now - 60}, secret), key: secret, wantErr: ErrTokenExpired},
+		{name: "not yet valid", token: signToken(t, "HS256", map[string]interface{}{"nbf": now + 3600}, secret), key: secret, wantErr: ErrTokenExpired},
+		{name: "already valid", token: signToken(t, "HS256", map[string]interface{}{"nbf": now - 60}, secret), key: secret},
+		{name: "malformed", token: "not.a-token", key: secret, wantErr: ErrMalformedToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseToken(tt.token, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBearerSchemeCaseInsensitive(t *testing.T) {
+	secret := []byte("secret")
+	token := signToken(t, "HS256", map[string]interface{}{"sub": "user-1"}, secret)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ValidateClaimsWithConfig(nil, Config{Config: core.DefaultConfig(), Key: secret}))
+	router.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		header     string
+		wantStatus int
+	}{
+		{"Bearer " + token, http.StatusOK},
+		{"bearer " + token, http.StatusOK},
+		{"BEARER " + token, http.StatusOK},
+		{"Basic " + token, http.StatusUnauthorized},
+		{token, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", tt.header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("Authorization %.12q...: status = %d, want %d", tt.header, w.Code, tt.wantStatus)
+		}
+	}
+}